@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Symbol is an exported identifier of a package.
+type Symbol struct {
+	// Name is the identifier, qualified by the receiver type for methods
+	// (e.g. "Client.Do").
+	Name string `json:"name"`
+	// Kind is one of "const", "var", "func", "type" or "method".
+	Kind string `json:"kind"`
+	// Signature is the Go declaration of the symbol without its body.
+	Signature string `json:"signature"`
+	// Synopsis is the first sentence of the doc comment.
+	Synopsis string `json:"synopsis"`
+	// Position is the source position of the declaration in the form "file:line:column".
+	Position string `json:"position"`
+}
+
+// SymbolChange is a symbol whose signature has changed.
+type SymbolChange struct {
+	Old Symbol
+	New Symbol
+}
+
+// APIDiff is the difference of exported symbols between two versions of a package.
+type APIDiff struct {
+	Added   []Symbol
+	Removed []Symbol
+	Changed []SymbolChange
+}
+
+// Empty reports whether there are no API changes.
+func (d APIDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// apiSymbols returns the symbols documented in pkg sorted by name, which are
+// the exported ones unless pkg is computed with doc.AllDecls.
+func apiSymbols(fset *token.FileSet, pkg *doc.Package) []Symbol {
+	var symbols []Symbol
+
+	addValues := func(kind string, values []*doc.Value) {
+		for _, v := range values {
+			for _, spec := range v.Decl.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				spec := *vs
+				spec.Doc = nil
+				spec.Comment = nil
+				sig := kind + " " + nodeString(fset, &spec)
+				synopsis := pkg.Synopsis(v.Doc)
+				if vs.Doc != nil {
+					synopsis = pkg.Synopsis(vs.Doc.Text())
+				}
+				for _, name := range vs.Names {
+					symbols = append(symbols, Symbol{
+						Name:      name.Name,
+						Kind:      kind,
+						Signature: sig,
+						Synopsis:  synopsis,
+						Position:  fset.Position(name.Pos()).String(),
+					})
+				}
+			}
+		}
+	}
+
+	addFuncs := func(kind, prefix string, funcs []*doc.Func) {
+		for _, f := range funcs {
+			decl := *f.Decl
+			decl.Doc = nil
+			decl.Body = nil
+			symbols = append(symbols, Symbol{
+				Name:      prefix + f.Name,
+				Kind:      kind,
+				Signature: nodeString(fset, &decl),
+				Synopsis:  pkg.Synopsis(f.Doc),
+				Position:  fset.Position(f.Decl.Name.Pos()).String(),
+			})
+		}
+	}
+
+	addValues("const", pkg.Consts)
+	addValues("var", pkg.Vars)
+	addFuncs("func", "", pkg.Funcs)
+
+	for _, t := range pkg.Types {
+		for _, spec := range t.Decl.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == t.Name {
+				spec := *ts
+				spec.Doc = nil
+				spec.Comment = nil
+				symbols = append(symbols, Symbol{
+					Name:      t.Name,
+					Kind:      "type",
+					Signature: "type " + nodeString(fset, &spec),
+					Synopsis:  pkg.Synopsis(t.Doc),
+					Position:  fset.Position(ts.Name.Pos()).String(),
+				})
+			}
+		}
+		addValues("const", t.Consts)
+		addValues("var", t.Vars)
+		addFuncs("func", "", t.Funcs)
+		addFuncs("method", t.Name+".", t.Methods)
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].Name < symbols[j].Name
+	})
+
+	return symbols
+}
+
+// diffAPI compares the exported symbols of two versions of a package.
+// Signatures are compared ignoring differences in white space.
+func diffAPI(old, new []Symbol) APIDiff {
+	var diff APIDiff
+
+	oldByName := map[string]Symbol{}
+	for _, s := range old {
+		oldByName[s.Name] = s
+	}
+
+	newByName := map[string]Symbol{}
+	for _, s := range new {
+		newByName[s.Name] = s
+
+		o, ok := oldByName[s.Name]
+		if !ok {
+			diff.Added = append(diff.Added, s)
+		} else if normalizeSpace(o.Signature) != normalizeSpace(s.Signature) {
+			diff.Changed = append(diff.Changed, SymbolChange{Old: o, New: s})
+		}
+	}
+
+	for _, s := range old {
+		if _, ok := newByName[s.Name]; !ok {
+			diff.Removed = append(diff.Removed, s)
+		}
+	}
+
+	return diff
+}
+
+func nodeString(fset *token.FileSet, node interface{}) string {
+	var buf bytes.Buffer
+	printerConfig := printer.Config{
+		Tabwidth: 4,
+		Mode:     printer.UseSpaces,
+	}
+	if err := printerConfig.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// renderAPIDiff renders d as Markdown lists of added, removed and changed symbols.
+func renderAPIDiff(d APIDiff) string {
+	var buf bytes.Buffer
+
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&buf, "### %s\n\n", title)
+		for _, item := range items {
+			fmt.Fprintf(&buf, "- %s\n", item)
+		}
+		buf.WriteString("\n")
+	}
+
+	var added, removed, changed []string
+	for _, s := range d.Added {
+		added = append(added, "`"+s.Head()+"`")
+	}
+	for _, s := range d.Removed {
+		removed = append(removed, "`"+s.Head()+"`")
+	}
+	for _, c := range d.Changed {
+		if c.New.Kind == "type" {
+			changed = append(changed, "`"+c.New.Head()+"`")
+		} else {
+			changed = append(changed, "`"+c.Old.Head()+"` → `"+c.New.Head()+"`")
+		}
+	}
+
+	list("Added", added)
+	list("Removed", removed)
+	list("Changed", changed)
+
+	return buf.String()
+}
+
+// Head returns a one-line description of the symbol. Types are described
+// only by their names as their definitions may be long.
+func (s Symbol) Head() string {
+	if s.Kind == "type" {
+		return "type " + s.Name
+	}
+	return normalizeSpace(s.Signature)
+}
+
+// loadDocPackage parses the non-test package in dir as of the git revision ref,
+// or the working tree if ref is empty, and returns its documentation.
+func loadDocPackage(dir, ref string, mode doc.Mode) (*token.FileSet, *doc.Package, error) {
+	bpkg, err := importDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parseDir(fset, dir, ref, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if pkg := documentedPackage(dir, pkgs); pkg != nil {
+		return fset, doc.New(pkg, bpkg.ImportPath, mode), nil
+	}
+
+	if ref != "" {
+		return nil, nil, withStatus(exitParseError, fmt.Errorf("no source found at %s", ref))
+	}
+	return nil, nil, withStatus(exitParseError, fmt.Errorf("no source found"))
+}
+
+// diffAPIBetween compares the exported symbols of the package in dir
+// between the git revisions from and to.
+func diffAPIBetween(dir, from, to string) (APIDiff, error) {
+	oldFset, oldPkg, err := loadDocPackage(dir, from, 0)
+	if err != nil {
+		return APIDiff{}, err
+	}
+
+	newFset, newPkg, err := loadDocPackage(dir, to, 0)
+	if err != nil {
+		return APIDiff{}, err
+	}
+
+	return diffAPI(apiSymbols(oldFset, oldPkg), apiSymbols(newFset, newPkg)), nil
+}
+
+// runExports implements "goreadme exports [-format text|json] [-unexported] [dir]",
+// which prints the index of the exported symbols of the package.
+func runExports(args []string) error {
+	flags := flag.NewFlagSet("exports", flag.ContinueOnError)
+	format := flags.String("format", "text", "output format: text or json")
+	unexported := flags.Bool("unexported", false, "include unexported symbols")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return withStatus(exitUsage, fmt.Errorf("invalid -format: %q", *format))
+	}
+
+	dir := "."
+	if flags.NArg() >= 1 {
+		dir = flags.Arg(0)
+	}
+
+	var mode doc.Mode
+	if *unexported {
+		mode = doc.AllDecls
+	}
+
+	fset, pkg, err := loadDocPackage(dir, "", mode)
+	if err != nil {
+		return err
+	}
+
+	symbols := apiSymbols(fset, pkg)
+
+	if *format == "json" {
+		if symbols == nil {
+			symbols = []Symbol{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(symbols)
+	}
+
+	for _, s := range symbols {
+		_, err := fmt.Printf("%s\t%s\t%s\t%s\n", s.Position, s.Kind, s.Name, s.Synopsis)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
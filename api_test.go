@@ -0,0 +1,346 @@
+package main
+
+import (
+	"errors"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// gitPackage creates a git repository of a package whose foo.go is each of
+// versions in turn, committed and tagged "v1", "v2" and so on.
+func gitPackage(t *testing.T, versions ...string) string {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) {
+		if _, err := gitOutput(dir, append([]string{"-c", "user.name=alice", "-c", "user.email=alice@example.com"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	for i, src := range versions {
+		if err := ioutil.WriteFile(filepath.Join(dir, "foo.go"), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", ".")
+		git("commit", "-q", "-m", "version")
+		git("tag", "v"+string(rune('1'+i)))
+	}
+	return dir
+}
+
+func docPackage(t *testing.T, src string) (*token.FileSet, *doc.Package) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := &ast.Package{Name: f.Name.Name, Files: map[string]*ast.File{"foo.go": f}}
+	return fset, doc.New(pkg, "example.com/foo", 0)
+}
+
+func TestAPISymbols(t *testing.T) {
+	fset, pkg := docPackage(t, `package foo
+
+// Version is the version.
+const Version = "1.0"
+
+var (
+	// ErrFoo is an error.
+	ErrFoo, ErrBar error
+)
+
+// Client is a client.
+type Client struct {
+	Name string
+	secret string
+}
+
+// New returns a Client.
+func New() *Client { return nil }
+
+// Do does something.
+func (c *Client) Do(n int) error { return nil }
+
+func (c *Client) do() {}
+
+func unexported() {}
+`)
+
+	var got []string
+	for _, s := range apiSymbols(fset, pkg) {
+		got = append(got, s.Kind+" "+s.Name+": "+normalizeSpace(s.Signature)+" // "+s.Synopsis)
+	}
+	expected := []string{
+		"type Client: type Client struct { Name string // contains filtered or unexported fields } // Client is a client.",
+		"method Client.Do: func (c *Client) Do(n int) error // Do does something.",
+		"var ErrBar: var ErrFoo, ErrBar error // ErrFoo is an error.",
+		"var ErrFoo: var ErrFoo, ErrBar error // ErrFoo is an error.",
+		"func New: func New() *Client // New returns a Client.",
+		"const Version: const Version = \"1.0\" // Version is the version.",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("apiSymbols:\nGot ---\n%s\nExpected ---\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestDiffAPI(t *testing.T) {
+	sym := func(name, sig string) Symbol {
+		return Symbol{Name: name, Kind: "func", Signature: sig}
+	}
+
+	old := []Symbol{
+		sym("A", "func A()"),
+		sym("B", "func B(n int)"),
+		sym("C", "func C(n  int)\n"),
+	}
+	new := []Symbol{
+		sym("B", "func B(n int64)"),
+		sym("C", "func C(n int)"),
+		sym("D", "func D()"),
+	}
+
+	got := diffAPI(old, new)
+	expected := APIDiff{
+		Added:   []Symbol{sym("D", "func D()")},
+		Removed: []Symbol{sym("A", "func A()")},
+		Changed: []SymbolChange{{Old: sym("B", "func B(n int)"), New: sym("B", "func B(n int64)")}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("diffAPI:\nGot ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+
+	if d := diffAPI(old, old); !d.Empty() {
+		t.Errorf("diffAPI of the same symbols = %+v, expected empty", d)
+	}
+}
+
+func TestReleaseBody(t *testing.T) {
+	dir := gitPackage(t, `// Package foo does foo.
+package foo
+
+func A() {}
+
+func B(n int) {}
+`, `// Package foo does foo.
+package foo
+
+func B(n int64) {}
+
+func C() {}
+`, `// Package foo does foo and bar.
+package foo
+
+func B(n int64) {}
+
+func C() {}
+`)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		from, to string
+		expected string
+	}{
+		{"v1", "v2", "Package foo does foo.\n\n" +
+			"## API changes since v1\n\n" +
+			"### Added\n\n- `func C()`\n\n" +
+			"### Removed\n\n- `func A()`\n\n" +
+			"### Changed\n\n- `func B(n int)` → `func B(n int64)`\n\n"},
+		{"v2", "v3", "Package foo does foo and bar.\n\nNo API changes since v2.\n"},
+	}
+	for _, test := range tests {
+		got, err := releaseBody(dir, test.from, test.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.expected {
+			t.Errorf("releaseBody(%q, %q):\nGot ---\n%q\nExpected ---\n%q", test.from, test.to, got, test.expected)
+		}
+	}
+}
+
+func TestDiffAPIBetween(t *testing.T) {
+	dir := gitPackage(t, `package foo
+
+type Client struct{}
+
+func A() {}
+
+func B(n int) {}
+`, `package foo
+
+type Client struct {
+	Name string
+}
+
+func B(n int64) {}
+
+func C() {}
+`)
+	defer os.RemoveAll(dir)
+
+	// the working tree
+	if err := ioutil.WriteFile(filepath.Join(dir, "foo.go"), []byte("package foo\n\ntype Client struct {\n\tName string\n}\n\nfunc B(n int64) {}\n\nfunc C() {}\n\nfunc D() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from, to string
+		expected string
+	}{
+		{"v1", "v2", "### Added\n\n- `func C()`\n\n" +
+			"### Removed\n\n- `func A()`\n\n" +
+			"### Changed\n\n- `func B(n int)` → `func B(n int64)`\n- `type Client`\n\n"},
+		{"v2", "", "### Added\n\n- `func D()`\n\n"},
+		{"v2", "v2", ""},
+	}
+	for _, test := range tests {
+		d, err := diffAPIBetween(dir, test.from, test.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := renderAPIDiff(d); got != test.expected {
+			t.Errorf("renderAPIDiff(diffAPIBetween(%q, %q)):\nGot ---\n%q\nExpected ---\n%q", test.from, test.to, got, test.expected)
+		}
+		if d.Empty() != (test.expected == "") {
+			t.Errorf("diffAPIBetween(%q, %q).Empty() = %v", test.from, test.to, d.Empty())
+		}
+	}
+}
+
+func TestSection_apiChanges(t *testing.T) {
+	r := testReadme(t, map[string]string{"bar.go": "package bar\n\nfunc C() {}\n"}, loadOptions{})
+	r.Since = "v1.0.0"
+
+	tests := []struct {
+		diff     *APIDiff
+		expected string
+	}{
+		{nil, ""},
+		{&APIDiff{}, "## API changes since v1.0.0\n\nNone.\n"},
+		{
+			&APIDiff{Added: []Symbol{{Name: "C", Kind: "func", Signature: "func C()"}}},
+			"## API changes since v1.0.0\n\n### Added\n\n- `func C()`\n",
+		},
+	}
+	for _, test := range tests {
+		r.APIChanges = test.diff
+		if got := renderSections(t, r, "api-changes"); got != test.expected {
+			t.Errorf("api-changes section of %+v:\nGot ---\n%q\nExpected ---\n%q", test.diff, got, test.expected)
+		}
+	}
+}
+
+func TestLoadDocPackage_ignoredMain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/foo\n",
+		"foo.go": "package foo\n\nfunc New() {}\n",
+		"gen.go": "//go:build ignore\n\npackage main\n\nfunc main() {}\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// not whichever the map of the parsed packages yields first
+	for i := 0; i < 5; i++ {
+		_, pkg, err := loadDocPackage(dir, "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkg.Name != "foo" {
+			t.Fatalf("loadDocPackage = package %s, expected foo", pkg.Name)
+		}
+	}
+}
+
+func TestRunExports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := "package bar\n\n// Version is the version.\nconst Version = \"1.0\"\n\n// New returns a Client.\nfunc New() {}\n\nfunc unexported() {}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "bar.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bar := filepath.Join(dir, "bar.go")
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			[]string{dir},
+			bar + ":7:6\tfunc\tNew\tNew returns a Client.\n" +
+				bar + ":4:7\tconst\tVersion\tVersion is the version.\n",
+		},
+		{
+			[]string{"-unexported", dir},
+			bar + ":7:6\tfunc\tNew\tNew returns a Client.\n" +
+				bar + ":4:7\tconst\tVersion\tVersion is the version.\n" +
+				bar + ":9:6\tfunc\tunexported\t\n",
+		},
+		{
+			[]string{"-format", "json", dir},
+			`[
+  {
+    "name": "New",
+    "kind": "func",
+    "signature": "func New()",
+    "synopsis": "New returns a Client.",
+    "position": "` + bar + `:7:6"
+  },
+  {
+    "name": "Version",
+    "kind": "const",
+    "signature": "const Version = \"1.0\"",
+    "synopsis": "Version is the version.",
+    "position": "` + bar + `:4:7"
+  }
+]
+`,
+		},
+	}
+	for _, test := range tests {
+		got, err := captureStdout(t, func() error { return runExports(test.args) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.expected {
+			t.Errorf("exports %q:\nGot ---\n%s\nExpected ---\n%s", test.args, got, test.expected)
+		}
+	}
+
+	_, err = captureStdout(t, func() error { return runExports([]string{"-format", "xml", dir}) })
+	var e *exitError
+	if !errors.As(err, &e) || e.status != exitUsage {
+		t.Errorf("exports -format xml = %v, expected a usage error", err)
+	}
+
+	// the flags are checked before the package is loaded
+	if err := ioutil.WriteFile(filepath.Join(dir, "broken.go"), []byte("package bar\n\nfunc {\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = captureStdout(t, func() error { return runExports([]string{"-format", "xml", dir}) })
+	if !errors.As(err, &e) || e.status != exitUsage {
+		t.Errorf("exports -format xml of an unparseable package = %v, expected a usage error", err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// authorsFileNames and maintainersFileNames are the names of the files
+// listing the people of the project, one per line, e.g. "Name <email>".
+var (
+	authorsFileNames     = []string{"AUTHORS", "AUTHORS.md", "AUTHORS.txt"}
+	maintainersFileNames = []string{"MAINTAINERS", "MAINTAINERS.md", "MAINTAINERS.txt"}
+)
+
+// readPeopleFile reads the first of the files found in dir or its ancestors
+// up to the repository root, and returns the people listed in it.
+// Handles like "@motemen" are linked to their pages on repo, if known.
+func readPeopleFile(dir string, repo *Repository, names ...string) []Person {
+	path := findFileUp(dir, names...)
+	if path == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var people []Person
+	for _, line := range strings.Split(string(b), "\n") {
+		if p, ok := parsePerson(line, repo); ok {
+			people = append(people, p)
+		}
+	}
+	return people
+}
+
+// parsePerson parses a line of AUTHORS or MAINTAINERS such as
+// "Name <email> (@handle)", "Name (https://example.com)" or "- Name".
+// Comments and Markdown headings starting with "#" are skipped.
+func parsePerson(line string, repo *Repository) (Person, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Person{}, false
+	}
+	for _, bullet := range []string{"- ", "* "} {
+		line = strings.TrimPrefix(line, bullet)
+	}
+
+	var (
+		p    Person
+		name []string
+	)
+	for _, f := range strings.Fields(line) {
+		v := strings.Trim(f, "<>()[],")
+		switch {
+		case strings.HasPrefix(f, "<") && strings.Contains(v, "@"):
+			p.Email = v
+		case strings.HasPrefix(v, "https://") || strings.HasPrefix(v, "http://"):
+			p.URL = v
+		case strings.HasPrefix(v, "@") && len(v) > 1:
+			if p.URL == "" && repo != nil {
+				p.URL = repo.Forge().AuthorFromPath(Repository{Kind: repo.Kind, Host: repo.Host, Path: v[1:]}).URL
+			}
+			if len(name) == 0 {
+				name = append(name, v[1:])
+			}
+		default:
+			name = append(name, f)
+		}
+	}
+
+	p.Name = strings.Join(name, " ")
+	if p.Name == "" {
+		p.Name = p.Email
+	}
+	return p, p.Name != ""
+}
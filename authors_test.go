@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsePerson(t *testing.T) {
+	repo := &Repository{Kind: "github", Host: "github.com", Path: "motemen/goreadme"}
+
+	tests := []struct {
+		line     string
+		expected Person
+		ok       bool
+	}{
+		{"# This is the official list of authors.", Person{}, false},
+		{"", Person{}, false},
+		{"Hiroshi Motemen <motemen@example.com>", Person{Name: "Hiroshi Motemen", Email: "motemen@example.com"}, true},
+		{"- Alice (@alice)", Person{Name: "Alice", URL: "https://github.com/alice"}, true},
+		{"* @bob", Person{Name: "bob", URL: "https://github.com/bob"}, true},
+		{"Carol <carol@example.com> (https://carol.example.com)", Person{Name: "Carol", Email: "carol@example.com", URL: "https://carol.example.com"}, true},
+		{"<dave@example.com>", Person{Name: "dave@example.com", Email: "dave@example.com"}, true},
+		{"Example Inc.", Person{Name: "Example Inc."}, true},
+	}
+
+	for _, test := range tests {
+		p, ok := parsePerson(test.line, repo)
+		if p != test.expected || ok != test.ok {
+			t.Errorf("parsePerson(%q) = %+v, %v, expected %+v, %v", test.line, p, ok, test.expected, test.ok)
+		}
+	}
+}
+
+func TestReadPeopleFile(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	pkgDir := filepath.Join(root, "sub")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "AUTHORS"), []byte("# authors\n\nAlice <alice@example.com>\nBob <bob@example.com>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readPeopleFile(pkgDir, nil, authorsFileNames...)
+	expected := []Person{{Name: "Alice", Email: "alice@example.com"}, {Name: "Bob", Email: "bob@example.com"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("readPeopleFile() = %+v, expected %+v", got, expected)
+	}
+
+	if got := readPeopleFile(pkgDir, nil, maintainersFileNames...); got != nil {
+		t.Errorf("readPeopleFile() = %+v, expected nil", got)
+	}
+}
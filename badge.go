@@ -0,0 +1,582 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Badge is a badge shown at the top of the README.
+type Badge struct {
+	// Kind is the kind of the badge, e.g. "actions", by which badges are
+	// ordered. User-defined badges are of kind "custom".
+	Kind string `yaml:"-"`
+	// Name is the alternative text of the badge image.
+	Name string `yaml:"name"`
+	// Image is the URL of the badge image.
+	Image string `yaml:"image"`
+	// Link is the URL the badge links to, if any.
+	Link string `yaml:"link"`
+}
+
+// Markdown returns the Markdown of the badge. If style is not empty,
+// it is applied to the badges of shields.io.
+func (b Badge) Markdown(style string) string {
+	image := b.Image
+	if style != "" && strings.HasPrefix(image, "https://img.shields.io/") {
+		sep := "?"
+		if strings.Contains(image, "?") {
+			sep = "&"
+		}
+		image += sep + "style=" + style
+	}
+
+	img := "![" + b.Name + "](" + image + ")"
+	if b.Link == "" {
+		return img
+	}
+	return "[" + img + "](" + b.Link + ")"
+}
+
+// BadgeProvider detects the badges of a service, such as a CI service,
+// used by a package.
+type BadgeProvider interface {
+	// Kind returns the kind of the badges detected, e.g. "actions".
+	Kind() string
+	// Detect returns the badges for the package t.
+	Detect(t BadgeTarget) []Badge
+}
+
+// BadgeTarget is the package which badges are detected for.
+type BadgeTarget struct {
+	// Dir is the directory of the package.
+	Dir string
+	// ImportPath is the import path of the package.
+	ImportPath string
+	// Repo is the repository of the package, or nil if it is not known.
+	Repo *Repository
+	// Branch is the branch of the repository the badges show the status of.
+	Branch string
+}
+
+// badgeProviderFunc is a BadgeProvider detecting badges by a function.
+type badgeProviderFunc struct {
+	kind   string
+	detect func(t BadgeTarget) []Badge
+}
+
+func (p badgeProviderFunc) Kind() string { return p.kind }
+
+func (p badgeProviderFunc) Detect(t BadgeTarget) []Badge {
+	return p.detect(t)
+}
+
+// hostBadgeProvider is a BadgeProvider of the CI service built in the
+// hosting service of the kind host.
+type hostBadgeProvider struct {
+	kind string
+	host string
+}
+
+func (p hostBadgeProvider) Kind() string { return p.kind }
+
+func (p hostBadgeProvider) Detect(t BadgeTarget) []Badge {
+	if t.Repo == nil || t.Repo.Kind != p.host {
+		return nil
+	}
+	return hosts[p.host].BadgeURLs(t)
+}
+
+// badgeProviders are the providers of the detected badges, in the default
+// order of the badges.
+var badgeProviders = []BadgeProvider{
+	badgeProviderFunc{"travis", travisBadges},
+	badgeProviderFunc{"circleci", circleCIBadges},
+	badgeProviderFunc{"appveyor", appVeyorBadges},
+	hostBadgeProvider{"gitlab", "gitlab"},
+	hostBadgeProvider{"bitbucket", "bitbucket"},
+	hostBadgeProvider{"actions", "github"},
+	hostBadgeProvider{"gitea", "gitea"},
+	badgeProviderFunc{"woodpecker", woodpeckerBadges},
+	hostBadgeProvider{"sourcehut", "sourcehut"},
+	badgeProviderFunc{"codecov", codecovBadges},
+	badgeProviderFunc{"coveralls", coverallsBadges},
+	badgeProviderFunc{"release", releaseBadges},
+	badgeProviderFunc{"docker", func(t BadgeTarget) []Badge {
+		return dockerBadges(detectDockerImage(t.Dir, t.Repo))
+	}},
+	badgeProviderFunc{"license", func(t BadgeTarget) []Badge {
+		if l := detectLicense(t.Dir); l != nil {
+			return l.Badges()
+		}
+		return nil
+	}},
+}
+
+// badgeKinds returns the kinds of badges, in the default order.
+// User-defined badges are of kind "custom".
+func badgeKinds() []string {
+	kinds := make([]string, 0, len(badgeProviders)+1)
+	for _, p := range badgeProviders {
+		kinds = append(kinds, p.Kind())
+	}
+	return append(kinds, "custom")
+}
+
+// detectBadges returns the badges detected by badgeProviders for
+// the package t, except the ones of the kinds in disabled.
+func detectBadges(t BadgeTarget, disabled []string) []Badge {
+	var badges []Badge
+	for _, p := range badgeProviders {
+		if containsString(disabled, p.Kind()) {
+			continue
+		}
+		badges = append(badges, p.Detect(t)...)
+	}
+	return badges
+}
+
+// badgeStyles are the styles of the badges of shields.io.
+var badgeStyles = []string{"flat", "flat-square", "plastic", "for-the-badge", "social"}
+
+// checkBadgeOptions validates the kinds of badges in order and disabled,
+// and style.
+func checkBadgeOptions(order, disabled []string, style string) error {
+	kinds := badgeKinds()
+	for _, kind := range append(append([]string{}, order...), disabled...) {
+		if !containsString(kinds, kind) {
+			return fmt.Errorf("unknown badge kind %q (known kinds: %s)", kind, strings.Join(kinds, ", "))
+		}
+	}
+	if style != "" && !containsString(badgeStyles, style) {
+		return fmt.Errorf("unknown badge style %q (known styles: %s)", style, strings.Join(badgeStyles, ", "))
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sortBadges sorts badges by their kinds in order. Badges of the kinds not
+// in order follow them in the default order of the kinds.
+func sortBadges(badges []Badge, order []string) {
+	kinds := badgeKinds()
+	rank := func(kind string) int {
+		for i, k := range order {
+			if k == kind {
+				return i
+			}
+		}
+		for i, k := range kinds {
+			if k == kind {
+				return len(order) + i
+			}
+		}
+		return len(order) + len(kinds)
+	}
+
+	sort.SliceStable(badges, func(i, j int) bool {
+		return rank(badges[i].Kind) < rank(badges[j].Kind)
+	})
+}
+
+// repoRoot returns the root directory of the git repository containing dir,
+// or "" if dir is not in a repository.
+func repoRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// defaultBranch returns the default branch of the origin remote of
+// the repository in dir. If it is not known, the local branch "main" or
+// "master" is assumed if there is only one of them, otherwise fallback.
+func defaultBranch(dir, fallback string) string {
+	out, err := gitOutput(dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err == nil && strings.HasPrefix(out, "origin/") {
+		return out[len("origin/"):]
+	}
+
+	hasBranch := func(name string) bool {
+		_, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+name)
+		return err == nil
+	}
+	switch main, master := hasBranch("main"), hasBranch("master"); {
+	case main && !master:
+		return "main"
+	case master && !main:
+		return "master"
+	}
+
+	return fallback
+}
+
+// shieldsEscape escapes s for the text of the static badges of shields.io,
+// where dashes and underscores are separators.
+func shieldsEscape(s string) string {
+	return strings.NewReplacer("-", "--", "_", "__", "/", "%2F", " ", "%20").Replace(s)
+}
+
+// travisBadges returns the Travis CI badge if the package in dir has
+// .travis.yml.
+func travisBadges(t BadgeTarget) []Badge {
+	if _, err := os.Stat(filepath.Join(t.Dir, ".travis.yml")); err != nil {
+		return nil
+	}
+	if t.Repo == nil || t.Repo.Kind != "github" {
+		return nil
+	}
+	repo := t.Repo.Path
+
+	// [![Build Status](https://travis-ci.org/motemen/go-sqlf.svg?branch=master)](https://travis-ci.org/motemen/go-sqlf)
+	return []Badge{{
+		Kind:  "travis",
+		Name:  "Build Status",
+		Image: fmt.Sprintf("https://travis-ci.org/%s.svg?branch=%s", repo, t.Branch),
+		Link:  "https://travis-ci.org/" + repo,
+	}}
+}
+
+// repoHasFile reports whether the repository containing dir has any of
+// the files at its root.
+func repoHasFile(dir string, names ...string) bool {
+	root := repoRoot(dir)
+	if root == "" {
+		return false
+	}
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// findFileUp returns the path of the first of the files found in dir or
+// its ancestors up to the repository root, or "" if none is found.
+func findFileUp(dir string, names ...string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	root := repoRoot(dir)
+
+	for d := dir; ; {
+		for _, name := range names {
+			path := filepath.Join(d, name)
+			if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+				return path
+			}
+		}
+
+		parent := filepath.Dir(d)
+		if root == "" || d == root || parent == d {
+			return ""
+		}
+		d = parent
+	}
+}
+
+// circleCIBadges returns the CircleCI badge if the repository of
+// the package in dir has .circleci/config.yml.
+func circleCIBadges(t BadgeTarget) []Badge {
+	if t.Repo == nil || t.Repo.Kind != "github" || !repoHasFile(t.Dir, filepath.Join(".circleci", "config.yml")) {
+		return nil
+	}
+
+	url := "https://circleci.com/gh/" + t.Repo.Path + "/tree/" + t.Branch
+	return []Badge{{
+		Kind:  "circleci",
+		Name:  "CircleCI",
+		Image: url + ".svg?style=svg",
+		Link:  url,
+	}}
+}
+
+// appVeyorBadges returns the AppVeyor badge if the repository of
+// the package in dir has appveyor.yml.
+func appVeyorBadges(t BadgeTarget) []Badge {
+	if t.Repo == nil || t.Repo.Kind != "github" || !repoHasFile(t.Dir, "appveyor.yml", ".appveyor.yml") {
+		return nil
+	}
+	repo := t.Repo.Path
+
+	return []Badge{{
+		Kind:  "appveyor",
+		Name:  "Build status",
+		Image: "https://ci.appveyor.com/api/projects/status/github/" + repo + "?branch=" + t.Branch + "&svg=true",
+		Link:  "https://ci.appveyor.com/project/" + repo,
+	}}
+}
+
+// gitlabCIBadges returns the pipeline status badge of GitLab CI/CD if
+// the repository of the package in dir has .gitlab-ci.yml.
+func gitlabCIBadges(t BadgeTarget) []Badge {
+	if t.Repo == nil || t.Repo.Kind != "gitlab" || !repoHasFile(t.Dir, ".gitlab-ci.yml") {
+		return nil
+	}
+
+	url := t.Repo.URL()
+	return []Badge{{
+		Kind:  "gitlab",
+		Name:  "pipeline status",
+		Image: url + "/badges/" + t.Branch + "/pipeline.svg",
+		Link:  url + "/-/commits/" + t.Branch,
+	}}
+}
+
+// bitbucketPipelinesBadges returns the build status badge of Bitbucket
+// Pipelines if the repository of the package in dir has
+// bitbucket-pipelines.yml.
+func bitbucketPipelinesBadges(t BadgeTarget) []Badge {
+	if t.Repo == nil || t.Repo.Kind != "bitbucket" || !repoHasFile(t.Dir, "bitbucket-pipelines.yml") {
+		return nil
+	}
+
+	return []Badge{{
+		Kind:  "bitbucket",
+		Name:  "Build Status",
+		Image: "https://img.shields.io/bitbucket/pipelines/" + t.Repo.Path + "/" + t.Branch + ".svg",
+		Link:  t.Repo.URL() + "/addon/pipelines/home",
+	}}
+}
+
+// workflowFiles returns the workflow files in the directory workflowDir
+// relative to the root of the repository containing dir, in order.
+func workflowFiles(dir, workflowDir string) []string {
+	root := repoRoot(dir)
+	if root == "" {
+		return nil
+	}
+
+	var files []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, _ := filepath.Glob(filepath.Join(root, workflowDir, pattern))
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	return files
+}
+
+// workflowName returns the name of the workflow in file, which defaults to
+// its base name without the extension.
+func workflowName(file string) string {
+	var workflow struct {
+		Name string `yaml:"name"`
+	}
+	if b, err := ioutil.ReadFile(file); err == nil {
+		_ = yaml.Unmarshal(b, &workflow)
+	}
+	if workflow.Name != "" {
+		return workflow.Name
+	}
+
+	base := filepath.Base(file)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// githubActionsBadges returns the status badges of the GitHub Actions
+// workflows in the repository of the package in dir, for the default branch.
+func githubActionsBadges(t BadgeTarget) []Badge {
+	if t.Repo == nil || t.Repo.Kind != "github" {
+		return nil
+	}
+
+	var badges []Badge
+	for _, file := range workflowFiles(t.Dir, filepath.Join(".github", "workflows")) {
+		url := t.Repo.URL() + "/actions/workflows/" + filepath.Base(file)
+		badges = append(badges, Badge{
+			Kind:  "actions",
+			Name:  workflowName(file),
+			Image: url + "/badge.svg?branch=" + t.Branch,
+			Link:  url,
+		})
+	}
+
+	return badges
+}
+
+// giteaActionsBadges returns the status badges of the Gitea (or Forgejo)
+// Actions workflows in the repository of the package in dir.
+func giteaActionsBadges(t BadgeTarget) []Badge {
+	if t.Repo == nil || t.Repo.Kind != "gitea" {
+		return nil
+	}
+
+	var badges []Badge
+	for _, workflowDir := range []string{".gitea", ".forgejo"} {
+		for _, file := range workflowFiles(t.Dir, filepath.Join(workflowDir, "workflows")) {
+			base := filepath.Base(file)
+			badges = append(badges, Badge{
+				Kind:  "gitea",
+				Name:  workflowName(file),
+				Image: t.Repo.URL() + "/actions/workflows/" + base + "/badge.svg?branch=" + t.Branch,
+				Link:  t.Repo.URL() + "/actions?workflow=" + base,
+			})
+		}
+	}
+
+	return badges
+}
+
+// woodpeckerBadges returns the status badge of Woodpecker CI on Codeberg
+// if the repository of the package in dir has its configuration.
+func woodpeckerBadges(t BadgeTarget) []Badge {
+	if t.Repo == nil || t.Repo.Host != "codeberg.org" || !repoHasFile(t.Dir, ".woodpecker.yml", ".woodpecker.yaml", ".woodpecker") {
+		return nil
+	}
+
+	return []Badge{{
+		Kind:  "woodpecker",
+		Name:  "status-badge",
+		Image: "https://ci.codeberg.org/api/badges/" + t.Repo.Path + "/status.svg?branch=" + t.Branch,
+		Link:  "https://ci.codeberg.org/repos/" + t.Repo.Path,
+	}}
+}
+
+// sourcehutBuildsBadges returns the status badge of builds.sr.ht if
+// the repository of the package in dir has its build manifests.
+func sourcehutBuildsBadges(t BadgeTarget) []Badge {
+	if t.Repo == nil || t.Repo.Kind != "sourcehut" || !repoHasFile(t.Dir, ".build.yml", ".builds") {
+		return nil
+	}
+
+	url := "https://builds.sr.ht/" + t.Repo.Path + "/commits/" + t.Branch
+	return []Badge{{
+		Kind:  "sourcehut",
+		Name:  "builds.sr.ht status",
+		Image: url + ".svg",
+		Link:  url,
+	}}
+}
+
+// usesCoverageService reports whether the repository at root uses the
+// coverage service, which is detected by its configuration files or
+// references in the CI configurations.
+func usesCoverageService(root, service string, configFiles ...string) bool {
+	for _, name := range configFiles {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			return true
+		}
+	}
+
+	ciConfigs := []string{filepath.Join(root, ".travis.yml")}
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, _ := filepath.Glob(filepath.Join(root, ".github", "workflows", pattern))
+		ciConfigs = append(ciConfigs, matches...)
+	}
+	for _, file := range ciConfigs {
+		if b, err := ioutil.ReadFile(file); err == nil && strings.Contains(strings.ToLower(string(b)), service) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// coverageServiceHosts are the names of the hosts of repositories on
+// Codecov and Coveralls, by the kinds of the hosts.
+var coverageServiceHosts = map[string]struct{ codecov, coveralls string }{
+	"github": {"gh", "github"},
+	"gitlab": {"gl", "gitlab"},
+}
+
+// coverageServiceHost returns the names of the host of t.Repo on Codecov
+// and Coveralls. Self-hosted repositories are not supported.
+func coverageServiceHost(t BadgeTarget) (codecov, coveralls string, ok bool) {
+	if t.Repo == nil || t.Repo.Host != t.Repo.Kind+".com" {
+		return "", "", false
+	}
+	h, ok := coverageServiceHosts[t.Repo.Kind]
+	return h.codecov, h.coveralls, ok
+}
+
+// codecovBadges returns the Codecov badge if the repository of the package
+// in dir uses it.
+func codecovBadges(t BadgeTarget) []Badge {
+	host, _, ok := coverageServiceHost(t)
+	if !ok {
+		return nil
+	}
+
+	root := repoRoot(t.Dir)
+	if root == "" || !usesCoverageService(root, "codecov", "codecov.yml", ".codecov.yml") {
+		return nil
+	}
+
+	url := "https://codecov.io/" + host + "/" + t.Repo.Path
+	return []Badge{{
+		Kind:  "codecov",
+		Name:  "codecov",
+		Image: url + "/branch/" + t.Branch + "/graph/badge.svg",
+		Link:  url,
+	}}
+}
+
+// coverallsBadges returns the Coveralls badge if the repository of
+// the package in dir uses it.
+func coverallsBadges(t BadgeTarget) []Badge {
+	_, host, ok := coverageServiceHost(t)
+	if !ok {
+		return nil
+	}
+	repo := host + "/" + t.Repo.Path
+
+	root := repoRoot(t.Dir)
+	if root == "" || !usesCoverageService(root, "coveralls", ".coveralls.yml") {
+		return nil
+	}
+
+	branch := t.Branch
+	return []Badge{{
+		Kind:  "coveralls",
+		Name:  "Coverage Status",
+		Image: fmt.Sprintf("https://coveralls.io/repos/%s/badge.svg?branch=%s", repo, branch),
+		Link:  fmt.Sprintf("https://coveralls.io/%s?branch=%s", repo, branch),
+	}}
+}
+
+// releaseBadges returns the badge of the latest release of the repository
+// of the package in dir, linking to its release page, if it has release tags.
+func releaseBadges(t BadgeTarget) []Badge {
+	if t.Repo == nil {
+		return nil
+	}
+
+	tag, err := latestRelease(t.Dir)
+	if err != nil || tag == "" {
+		return nil
+	}
+
+	return []Badge{{
+		Kind:  "release",
+		Name:  "Release",
+		Image: "https://img.shields.io/badge/release-" + shieldsEscape(tag) + "-blue.svg",
+		Link:  t.Repo.Forge().ReleaseLink(*t.Repo, tag),
+	}}
+}
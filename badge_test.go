@@ -0,0 +1,316 @@
+package main
+
+import (
+	"go/doc"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// badgeMarkdowns renders badges without style.
+func badgeMarkdowns(badges []Badge) []string {
+	var ss []string
+	for _, b := range badges {
+		ss = append(ss, b.Markdown(""))
+	}
+	return ss
+}
+
+func TestBadge_Markdown(t *testing.T) {
+	tests := []struct {
+		badge    Badge
+		style    string
+		expected string
+	}{
+		{
+			Badge{Name: "Test", Image: "https://example.com/badge.svg", Link: "https://example.com/"},
+			"flat-square",
+			"[![Test](https://example.com/badge.svg)](https://example.com/)",
+		},
+		{
+			Badge{Name: "Release", Image: "https://img.shields.io/badge/release-v1.0.0-blue.svg"},
+			"flat-square",
+			"![Release](https://img.shields.io/badge/release-v1.0.0-blue.svg?style=flat-square)",
+		},
+		{
+			Badge{Name: "Container", Image: "https://img.shields.io/badge/ghcr.io-foo-blue.svg?logo=docker", Link: "https://example.com/"},
+			"for-the-badge",
+			"[![Container](https://img.shields.io/badge/ghcr.io-foo-blue.svg?logo=docker&style=for-the-badge)](https://example.com/)",
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.badge.Markdown(test.style); got != test.expected {
+			t.Errorf("Markdown(%q) = %q, expected %q", test.style, got, test.expected)
+		}
+	}
+}
+
+func TestSortBadges(t *testing.T) {
+	badges := []Badge{
+		{Kind: "custom"},
+		{Kind: "actions", Name: "test"},
+		{Kind: "codecov"},
+		{Kind: "actions", Name: "lint"},
+		{Kind: "release"},
+		{Kind: "license"},
+	}
+	sortBadges(badges, []string{"license", "release"})
+
+	var names []string
+	for _, b := range badges {
+		names = append(names, b.Kind+":"+b.Name)
+	}
+	expected := []string{"license:", "release:", "actions:test", "actions:lint", "codecov:", "custom:"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("sortBadges mismatch:\nGot ---\n%q\nExpected ---\n%q\n", names, expected)
+	}
+
+	if err := checkBadgeOptions([]string{"release", "jenkins"}, nil, ""); err == nil {
+		t.Error("checkBadgeOptions should fail for unknown kinds")
+	}
+	if err := checkBadgeOptions(nil, []string{"travis"}, "square"); err == nil {
+		t.Error("checkBadgeOptions should fail for unknown styles")
+	}
+}
+
+func TestGithubActionsBadges(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	workflows := filepath.Join(root, ".github", "workflows")
+	if err := os.MkdirAll(workflows, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"test.yml":     "name: Test\non: push\n",
+		"release.yaml": "on: push\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(workflows, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "sub")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	badges := badgeMarkdowns(githubActionsBadges(BadgeTarget{Dir: dir, ImportPath: "github.com/motemen/goreadme/sub", Repo: resolveRepository("github.com/motemen/goreadme/sub", nil), Branch: "master"}))
+	expected := []string{
+		"[![release](https://github.com/motemen/goreadme/actions/workflows/release.yaml/badge.svg?branch=master)](https://github.com/motemen/goreadme/actions/workflows/release.yaml)",
+		"[![Test](https://github.com/motemen/goreadme/actions/workflows/test.yml/badge.svg?branch=master)](https://github.com/motemen/goreadme/actions/workflows/test.yml)",
+	}
+	if !reflect.DeepEqual(badges, expected) {
+		t.Errorf("githubActionsBadges mismatch:\nGot ---\n%q\nExpected ---\n%q\n", badges, expected)
+	}
+
+	if badges := githubActionsBadges(BadgeTarget{Dir: dir, ImportPath: "example.com/goreadme", Repo: resolveRepository("example.com/goreadme", nil), Branch: "master"}); badges != nil {
+		t.Errorf("githubActionsBadges should be empty for non-GitHub packages: %q", badges)
+	}
+}
+
+func TestCoverageBadges(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	target := BadgeTarget{Dir: root, ImportPath: "github.com/motemen/goreadme", Repo: resolveRepository("github.com/motemen/goreadme", nil), Branch: "master"}
+
+	if badges := append(codecovBadges(target), coverallsBadges(target)...); badges != nil {
+		t.Errorf("coverage badges should be empty without configurations: %q", badges)
+	}
+
+	workflows := filepath.Join(root, ".github", "workflows")
+	if err := os.MkdirAll(workflows, 0755); err != nil {
+		t.Fatal(err)
+	}
+	workflow := "steps:\n  - uses: codecov/codecov-action@v3\n"
+	if err := ioutil.WriteFile(filepath.Join(workflows, "test.yml"), []byte(workflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, ".coveralls.yml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	badges := badgeMarkdowns(append(codecovBadges(target), coverallsBadges(target)...))
+	expected := []string{
+		"[![codecov](https://codecov.io/gh/motemen/goreadme/branch/master/graph/badge.svg)](https://codecov.io/gh/motemen/goreadme)",
+		"[![Coverage Status](https://coveralls.io/repos/github/motemen/goreadme/badge.svg?branch=master)](https://coveralls.io/github/motemen/goreadme?branch=master)",
+	}
+	if !reflect.DeepEqual(badges, expected) {
+		t.Errorf("coverage badges mismatch:\nGot ---\n%q\nExpected ---\n%q\n", badges, expected)
+	}
+}
+
+func TestReleaseBadge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if _, err := gitOutput(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "initial")
+
+	if badges := releaseBadges(BadgeTarget{Dir: dir, ImportPath: "github.com/motemen/goreadme", Repo: resolveRepository("github.com/motemen/goreadme", nil)}); badges != nil {
+		t.Errorf("releaseBadges should be empty without tags: %q", badges)
+	}
+
+	for _, tag := range []string{"v0.9.0", "v1.10.0", "v1.2.0", "v2.0.0-rc.1", "release-1"} {
+		git("tag", tag)
+	}
+
+	badges := badgeMarkdowns(releaseBadges(BadgeTarget{Dir: dir, ImportPath: "github.com/motemen/goreadme", Repo: resolveRepository("github.com/motemen/goreadme", nil)}))
+	expected := []string{"[![Release](https://img.shields.io/badge/release-v1.10.0-blue.svg)](https://github.com/motemen/goreadme/releases/tag/v1.10.0)"}
+	if !reflect.DeepEqual(badges, expected) {
+		t.Errorf("releaseBadges mismatch:\nGot ---\n%q\nExpected ---\n%q\n", badges, expected)
+	}
+}
+
+func TestCIBadges(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, name := range []string{".git", ".circleci", ".gitea/workflows"} {
+		if err := os.MkdirAll(filepath.Join(root, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{".circleci/config.yml", "appveyor.yml", ".gitlab-ci.yml", "bitbucket-pipelines.yml", ".woodpecker.yml", ".build.yml", ".gitea/workflows/ci.yml"} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		detect     func(t BadgeTarget) []Badge
+		importPath string
+		expected   []string
+	}{
+		{
+			circleCIBadges, "github.com/motemen/goreadme",
+			[]string{"[![CircleCI](https://circleci.com/gh/motemen/goreadme/tree/main.svg?style=svg)](https://circleci.com/gh/motemen/goreadme/tree/main)"},
+		},
+		{
+			appVeyorBadges, "github.com/motemen/goreadme",
+			[]string{"[![Build status](https://ci.appveyor.com/api/projects/status/github/motemen/goreadme?branch=main&svg=true)](https://ci.appveyor.com/project/motemen/goreadme)"},
+		},
+		{
+			gitlabCIBadges, "gitlab.com/motemen/goreadme",
+			[]string{"[![pipeline status](https://gitlab.com/motemen/goreadme/badges/main/pipeline.svg)](https://gitlab.com/motemen/goreadme/-/commits/main)"},
+		},
+		{
+			gitlabCIBadges, "git.example.com/motemen/goreadme",
+			[]string{"[![pipeline status](https://git.example.com/motemen/goreadme/badges/main/pipeline.svg)](https://git.example.com/motemen/goreadme/-/commits/main)"},
+		},
+		{
+			gitlabCIBadges, "github.com/motemen/goreadme",
+			nil,
+		},
+		{
+			bitbucketPipelinesBadges, "bitbucket.org/motemen/goreadme",
+			[]string{"[![Build Status](https://img.shields.io/bitbucket/pipelines/motemen/goreadme/main.svg)](https://bitbucket.org/motemen/goreadme/addon/pipelines/home)"},
+		},
+		{
+			giteaActionsBadges, "codeberg.org/motemen/goreadme",
+			[]string{"[![ci](https://codeberg.org/motemen/goreadme/actions/workflows/ci.yml/badge.svg?branch=main)](https://codeberg.org/motemen/goreadme/actions?workflow=ci.yml)"},
+		},
+		{
+			woodpeckerBadges, "codeberg.org/motemen/goreadme",
+			[]string{"[![status-badge](https://ci.codeberg.org/api/badges/motemen/goreadme/status.svg?branch=main)](https://ci.codeberg.org/repos/motemen/goreadme)"},
+		},
+		{
+			sourcehutBuildsBadges, "git.sr.ht/~motemen/goreadme",
+			[]string{"[![builds.sr.ht status](https://builds.sr.ht/~motemen/goreadme/commits/main.svg)](https://builds.sr.ht/~motemen/goreadme/commits/main)"},
+		},
+		{
+			codecovBadges, "gitlab.com/motemen/goreadme",
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		if got := badgeMarkdowns(test.detect(BadgeTarget{Dir: root, ImportPath: test.importPath, Repo: resolveRepository(test.importPath, map[string]string{"git.example.com": "gitlab"}), Branch: "main"})); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("badges for %s mismatch:\nGot ---\n%q\nExpected ---\n%q\n", test.importPath, got, test.expected)
+		}
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if _, err := gitOutput(dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("checkout", "-q", "-b", "trunk")
+	git("commit", "-q", "--allow-empty", "-m", "initial")
+
+	if branch := defaultBranch(dir, "main"); branch != "main" {
+		t.Errorf("defaultBranch() = %q, expected %q", branch, "main")
+	}
+
+	git("branch", "main")
+
+	if branch := defaultBranch(dir, "master"); branch != "main" {
+		t.Errorf("defaultBranch() = %q, expected %q", branch, "main")
+	}
+
+	git("update-ref", "refs/remotes/origin/develop", "HEAD")
+	git("symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/develop")
+
+	if branch := defaultBranch(dir, "master"); branch != "develop" {
+		t.Errorf("defaultBranch() = %q, expected %q", branch, "develop")
+	}
+}
+
+func TestSection_badges(t *testing.T) {
+	tests := []struct {
+		docSite  string
+		expected string
+	}{
+		{"", "[![Go Reference](https://pkg.go.dev/badge/example.com/foo.svg)](https://pkg.go.dev/example.com/foo)\n"},
+		{"https://pkgsite.example.com/", "[![Go Reference](https://pkg.go.dev/badge/example.com/foo.svg)](https://pkgsite.example.com/example.com/foo)\n"},
+	}
+
+	for _, test := range tests {
+		r := &Readme{
+			Pkg:     &doc.Package{Name: "foo", ImportPath: "example.com/foo"},
+			DocSite: test.docSite,
+		}
+		if got := renderSections(t, r, "badges"); got != test.expected {
+			t.Errorf("badges section with DocSite %q = %q, expected %q", test.docSite, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"go/ast"
+	"go/doc"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TestFunc is a function in the test files run by "go test",
+// such as a benchmark.
+type TestFunc struct {
+	// Name is the name of the function, e.g. "BenchmarkDo".
+	Name string
+	// Doc is the doc comment of the function.
+	Doc string
+	// Synopsis is the first sentence of Doc.
+	Synopsis string
+	// Result is the result of the benchmark read from the file given
+	// by -bench-results, e.g. "1234 ns/op 16 B/op 1 allocs/op".
+	Result string
+}
+
+// collectTestFuncs returns the functions in the test files of pkgs
+// whose names have prefix, like "Benchmark", in alphabetical order.
+func collectTestFuncs(pkgs map[string]*ast.Package, prefix string) []TestFunc {
+	var funcs []TestFunc
+
+	for _, pkg := range pkgs {
+		for filename, f := range pkg.Files {
+			if !strings.HasSuffix(filename, "_test.go") {
+				continue
+			}
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || !isTestFuncName(fn.Name.Name, prefix) {
+					continue
+				}
+				tf := TestFunc{Name: fn.Name.Name}
+				if fn.Doc != nil {
+					tf.Doc = fn.Doc.Text()
+					tf.Synopsis = doc.Synopsis(tf.Doc)
+				}
+				funcs = append(funcs, tf)
+			}
+		}
+	}
+
+	sort.Slice(funcs, func(i, j int) bool {
+		return funcs[i].Name < funcs[j].Name
+	})
+
+	return funcs
+}
+
+// isTestFuncName reports whether name is of a function which "go test"
+// runs as prefix, e.g. "BenchmarkFoo" but not "Benchmarker".
+func isTestFuncName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// rxBenchProcs matches the GOMAXPROCS suffix of benchmark names.
+var rxBenchProcs = regexp.MustCompile(`-\d+$`)
+
+// readBenchResults reads the output of "go test -bench" in file and returns
+// the results keyed by the benchmark names. Sub-benchmarks are keyed by
+// their full names, e.g. "BenchmarkDo/small".
+func readBenchResults(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	results := map[string]string{}
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		// fields[1] is the number of iterations
+		name := rxBenchProcs.ReplaceAllString(fields[0], "")
+		results[name] = strings.Join(fields[2:], " ")
+	}
+
+	return results, s.Err()
+}
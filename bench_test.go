@@ -0,0 +1,118 @@
+package main
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsTestFuncName(t *testing.T) {
+	cases := map[string]bool{
+		"Benchmark":       true,
+		"BenchmarkDo":     true,
+		"Benchmark_do":    true,
+		"Benchmarker":     false,
+		"TestBenchmarkDo": false,
+	}
+
+	for name, expected := range cases {
+		if got := isTestFuncName(name, "Benchmark"); got != expected {
+			t.Errorf("isTestFuncName(%q) = %v, expected %v", name, got, expected)
+		}
+	}
+}
+
+func TestCollectTestFuncs_fuzz(t *testing.T) {
+	sources := map[string]string{
+		"foo.go": "package foo\n\nfunc FuzzNotATest(f *testing.F) {}\n",
+		"foo_test.go": `package foo
+
+import "testing"
+
+// FuzzParse fuzzes Parse. It never panics.
+func FuzzParse(f *testing.F) {}
+
+func FuzzDecode(f *testing.F) {}
+
+func Fuzzy(t *testing.T) {}
+
+func BenchmarkParse(b *testing.B) {}
+`,
+	}
+
+	fset := token.NewFileSet()
+	pkg := &ast.Package{Name: "foo", Files: map[string]*ast.File{}}
+	for name, src := range sources {
+		f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg.Files[name] = f
+	}
+
+	got := collectTestFuncs(map[string]*ast.Package{"foo": pkg}, "Fuzz")
+	expected := []TestFunc{
+		{Name: "FuzzDecode"},
+		{Name: "FuzzParse", Doc: "FuzzParse fuzzes Parse. It never panics.\n", Synopsis: "FuzzParse fuzzes Parse."},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("collectTestFuncs(Fuzz) = %+v, expected %+v", got, expected)
+	}
+}
+
+func TestSection_fuzz(t *testing.T) {
+	r := &Readme{Pkg: &doc.Package{Name: "foo", ImportPath: "example.com/foo"}}
+	if got := renderSections(t, r, "fuzz"); got != "" {
+		t.Errorf("fuzz section without targets = %q, expected empty", got)
+	}
+
+	r.FuzzTargets = []TestFunc{
+		{Name: "FuzzDecode"},
+		{Name: "FuzzParse", Synopsis: "FuzzParse fuzzes Parse."},
+	}
+	expected := "## Fuzz Targets\n\n- FuzzDecode\n- FuzzParse: FuzzParse fuzzes Parse.\n"
+	if got := renderSections(t, r, "fuzz"); got != expected {
+		t.Errorf("fuzz section:\nGot ---\n%s\nExpected ---\n%s", got, expected)
+	}
+}
+
+func TestReadBenchResults(t *testing.T) {
+	out := `goos: linux
+goarch: amd64
+pkg: github.com/motemen/goreadme
+BenchmarkDo-8         	 1000000	      1234 ns/op	      16 B/op	       1 allocs/op
+BenchmarkDo/small-8   	 2000000	       567 ns/op
+PASS
+ok  	github.com/motemen/goreadme	3.456s
+`
+
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "bench.txt")
+	if err := ioutil.WriteFile(file, []byte(out), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := readBenchResults(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"BenchmarkDo":       "1234 ns/op 16 B/op 1 allocs/op",
+		"BenchmarkDo/small": "567 ns/op",
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("readBenchResults = %v, expected %v", results, expected)
+	}
+}
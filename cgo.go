@@ -0,0 +1,120 @@
+package main
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"strconv"
+)
+
+// filterCgoFiles removes the files in pkgs which are not built with cgo
+// enabled if cgo is true, or disabled otherwise, so that the declarations
+// of the variants of a package for cgo and the others do not conflict.
+// The files importing "C" require cgo. The build constraints on the other
+// tags are not evaluated; see also buildContext.
+func filterCgoFiles(pkgs map[string]*ast.Package, cgo bool) {
+	for name, pkg := range pkgs {
+		for filename, f := range pkg.Files {
+			if importsC(f) && !cgo || !satisfiable(buildConstraint(f), "cgo", cgo) {
+				delete(pkg.Files, filename)
+			}
+		}
+		if len(pkg.Files) == 0 {
+			delete(pkgs, name)
+		}
+	}
+}
+
+// importsC reports whether f is a cgo file.
+func importsC(f *ast.File) bool {
+	for _, imp := range f.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil && path == "C" {
+			return true
+		}
+	}
+	return false
+}
+
+// buildConstraint returns the build constraint of f by the //go:build or
+// the // +build lines before the package clause, or nil if there is none.
+func buildConstraint(f *ast.File) constraint.Expr {
+	var plus []constraint.Expr
+	for _, g := range f.Comments {
+		if g.Pos() >= f.Package {
+			break
+		}
+		for _, c := range g.List {
+			if constraint.IsGoBuild(c.Text) {
+				if expr, err := constraint.Parse(c.Text); err == nil {
+					return expr
+				}
+			} else if constraint.IsPlusBuild(c.Text) {
+				if expr, err := constraint.Parse(c.Text); err == nil {
+					plus = append(plus, expr)
+				}
+			}
+		}
+	}
+
+	// multiple // +build lines are ANDed
+	var expr constraint.Expr
+	for _, x := range plus {
+		if expr == nil {
+			expr = x
+		} else {
+			expr = &constraint.AndExpr{X: expr, Y: x}
+		}
+	}
+	return expr
+}
+
+// satisfiable reports whether expr is satisfied by some combination of
+// the tags when tag is fixed to value.
+func satisfiable(expr constraint.Expr, tag string, value bool) bool {
+	if expr == nil {
+		return true
+	}
+
+	var tags []string
+	seen := map[string]bool{tag: true}
+	var collect func(constraint.Expr)
+	collect = func(x constraint.Expr) {
+		switch x := x.(type) {
+		case *constraint.TagExpr:
+			if !seen[x.Tag] {
+				seen[x.Tag] = true
+				tags = append(tags, x.Tag)
+			}
+		case *constraint.NotExpr:
+			collect(x.X)
+		case *constraint.AndExpr:
+			collect(x.X)
+			collect(x.Y)
+		case *constraint.OrExpr:
+			collect(x.X)
+			collect(x.Y)
+		}
+	}
+	collect(expr)
+	if len(tags) > 16 {
+		// too many to try
+		return true
+	}
+
+	for bits := 0; bits < 1<<uint(len(tags)); bits++ {
+		ok := expr.Eval(func(t string) bool {
+			if t == tag {
+				return value
+			}
+			for i, u := range tags {
+				if u == t {
+					return bits&(1<<uint(i)) != 0
+				}
+			}
+			return false
+		})
+		if ok {
+			return true
+		}
+	}
+	return false
+}
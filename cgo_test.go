@@ -0,0 +1,63 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFilterCgoFiles(t *testing.T) {
+	sources := map[string]string{
+		"add.go":         "package add\n\n// int add(int a, int b) { return a + b; }\nimport \"C\"\n\nfunc Add(a, b int) int { return 0 }\n",
+		"add_nocgo.go":   "//go:build !cgo\n\npackage add\n\nfunc Add(a, b int) int { return a + b }\n",
+		"add_linux.go":   "// +build linux,cgo\n\npackage add\n",
+		"add_other.go":   "//go:build !linux || !cgo\n\npackage add\n",
+		"doc.go":         "package add\n",
+		"cgo_only.go":    "//go:build cgo\n\npackage main\n",
+		"add_test.go":    "package add\n",
+		"windows_cgo.go": "//go:build windows && (cgo || !cgo)\n\npackage add\n",
+	}
+
+	tests := []struct {
+		cgo      bool
+		expected []string
+	}{
+		{true, []string{"add.go", "add_linux.go", "add_other.go", "add_test.go", "cgo_only.go", "doc.go", "windows_cgo.go"}},
+		{false, []string{"add_nocgo.go", "add_other.go", "add_test.go", "doc.go", "windows_cgo.go"}},
+	}
+	for _, test := range tests {
+		fset := token.NewFileSet()
+		pkgs := map[string]*ast.Package{}
+		for name, src := range sources {
+			f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkg, ok := pkgs[f.Name.Name]
+			if !ok {
+				pkg = &ast.Package{Name: f.Name.Name, Files: map[string]*ast.File{}}
+				pkgs[f.Name.Name] = pkg
+			}
+			pkg.Files[name] = f
+		}
+
+		filterCgoFiles(pkgs, test.cgo)
+
+		var got []string
+		for _, pkg := range pkgs {
+			for name := range pkg.Files {
+				got = append(got, name)
+			}
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("cgo=%v: Got ---\n%+v\nExpected ---\n%+v", test.cgo, got, test.expected)
+		}
+		if _, ok := pkgs["main"]; ok && !test.cgo {
+			t.Errorf("cgo=%v: package main should be removed", test.cgo)
+		}
+	}
+}
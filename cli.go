@@ -0,0 +1,294 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// cliFlagTypes are the types of the flags of github.com/urfave/cli, keyed
+// by the names of the flag types without the "Flag" suffix.
+var cliFlagTypes = map[string]string{
+	"Bool": "bool", "BoolT": "bool", "Int": "int", "Int64": "int64", "Uint": "uint", "Uint64": "uint64",
+	"String": "string", "Float64": "float64", "Duration": "duration", "Path": "path",
+	"StringSlice": "strings", "IntSlice": "ints", "Int64Slice": "int64s", "Float64Slice": "float64s",
+	"Timestamp": "timestamp", "Generic": "value",
+}
+
+// cliCommands statically analyzes the urfave/cli apps and commands defined
+// in files except tests and returns them in depth-first order from the
+// root, or nil if urfave/cli is not used. The root is named name unless
+// it has a Name. Commands are recognized as cli.App and cli.Command
+// literals, or cli.NewApp() calls whose fields are assigned afterwards,
+// with subcommands given as literals or as variables or functions
+// returning them.
+func cliCommands(fset *token.FileSet, files []*ast.File, name string) []Command {
+	// commands by "FUNC.VAR" for local variables, "VAR" for package-level
+	// ones and "FUNC()" for the ones returned from functions
+	cmds := map[string]*Command{}
+	parsed := map[*ast.CompositeLit]*Command{}
+	var order []*Command
+
+	type subcommand struct {
+		parent *Command
+		fn     string
+		expr   ast.Expr
+	}
+	var subcommands []subcommand
+
+	var newCommand func(fn string, expr ast.Expr, elided bool) *Command
+	var setField func(cmd *Command, fn, key string, value ast.Expr)
+
+	newCommand = func(fn string, expr ast.Expr, elided bool) *Command {
+		if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			expr = u.X
+		}
+		if call, ok := expr.(*ast.CallExpr); ok && isSelector(call.Fun, "cli", "NewApp") {
+			cmd := &Command{}
+			order = append(order, cmd)
+			return cmd
+		}
+		lit, ok := expr.(*ast.CompositeLit)
+		if !ok {
+			return nil
+		}
+		if cmd := parsed[lit]; cmd != nil {
+			return cmd
+		}
+		if !(elided && lit.Type == nil) && !isSelector(lit.Type, "cli", "App") && !isSelector(lit.Type, "cli", "Command") {
+			return nil
+		}
+		cmd := &Command{}
+		parsed[lit] = cmd
+		order = append(order, cmd)
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			if key, ok := kv.Key.(*ast.Ident); ok {
+				setField(cmd, fn, key.Name, kv.Value)
+			}
+		}
+		return cmd
+	}
+
+	setField = func(cmd *Command, fn, key string, value ast.Expr) {
+		s, _ := stringConstant(value)
+		switch key {
+		case "Name":
+			cmd.Path = s
+		case "Usage":
+			cmd.Short = s
+		case "Description":
+			cmd.Long = strings.TrimSpace(s)
+		case "Flags":
+			lit, ok := value.(*ast.CompositeLit)
+			if !ok {
+				break
+			}
+			for _, elt := range lit.Elts {
+				if flag, ok := cliFlag(fset, elt); ok {
+					cmd.Flags = append(cmd.Flags, flag)
+				}
+			}
+		case "Commands", "Subcommands":
+			lit, ok := value.(*ast.CompositeLit)
+			if !ok {
+				break
+			}
+			for _, elt := range lit.Elts {
+				if isHidden(elt) {
+					continue
+				}
+				if child := newCommand(fn, elt, true); child != nil {
+					child.isChild = true
+					cmd.children = append(cmd.children, child)
+				} else {
+					subcommands = append(subcommands, subcommand{cmd, fn, elt})
+				}
+			}
+		}
+	}
+
+	var sources []*ast.File
+	for _, f := range files {
+		if !strings.HasSuffix(fset.Position(f.Package).Filename, "_test.go") {
+			sources = append(sources, f)
+		}
+	}
+	files = sources
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if i < len(vs.Values) {
+						if cmd := newCommand("", vs.Values[i], false); cmd != nil {
+							cmds[name.Name] = cmd
+						}
+					}
+				}
+			}
+		}
+	}
+
+	lookup := func(fn string, expr ast.Expr) *Command {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			if cmd := cmds[fn+"."+e.Name]; cmd != nil {
+				return cmd
+			}
+			return cmds[e.Name]
+		case *ast.CallExpr:
+			if id, ok := e.Fun.(*ast.Ident); ok {
+				return cmds[id.Name+"()"]
+			}
+		}
+		return nil
+	}
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil || fn.Recv != nil {
+				continue
+			}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.AssignStmt:
+					for i, lhs := range n.Lhs {
+						if i >= len(n.Rhs) {
+							break
+						}
+						switch lhs := lhs.(type) {
+						case *ast.Ident:
+							if cmd := newCommand(fn.Name.Name, n.Rhs[i], false); cmd != nil {
+								cmds[fn.Name.Name+"."+lhs.Name] = cmd
+							}
+						case *ast.SelectorExpr:
+							// app.Name = "tool"
+							if cmd := lookup(fn.Name.Name, lhs.X); cmd != nil {
+								setField(cmd, fn.Name.Name, lhs.Sel.Name, n.Rhs[i])
+							}
+						}
+					}
+				case *ast.ReturnStmt:
+					if len(n.Results) != 1 {
+						break
+					}
+					if cmd := newCommand(fn.Name.Name, n.Results[0], false); cmd != nil {
+						cmds[fn.Name.Name+"()"] = cmd
+					} else if cmd := lookup(fn.Name.Name, n.Results[0]); cmd != nil {
+						cmds[fn.Name.Name+"()"] = cmd
+					}
+				case *ast.CompositeLit:
+					// (&cli.App{...}).Run(os.Args)
+					newCommand(fn.Name.Name, n, false)
+				}
+				return true
+			})
+		}
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	for _, sub := range subcommands {
+		if child := lookup(sub.fn, sub.expr); child != nil && child != sub.parent && !child.isChild {
+			child.isChild = true
+			sub.parent.children = append(sub.parent.children, child)
+		}
+	}
+
+	return flattenCommands(order, name)
+}
+
+// cliFlag parses a flag literal of urfave/cli, e.g. &cli.StringFlag{...}.
+// Hidden flags are ignored.
+func cliFlag(fset *token.FileSet, expr ast.Expr) (Flag, bool) {
+	if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		expr = u.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return Flag{}, false
+	}
+	sel, ok := lit.Type.(*ast.SelectorExpr)
+	if !ok || !isSelector(sel, "cli", sel.Sel.Name) || !strings.HasSuffix(sel.Sel.Name, "Flag") || isHidden(lit) {
+		return Flag{}, false
+	}
+
+	typ := strings.TrimSuffix(sel.Sel.Name, "Flag")
+	flag := Flag{Type: cliFlagTypes[typ]}
+	if typ == "BoolT" {
+		flag.Default = "true"
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		switch key.Name {
+		case "Name":
+			// v1 names the aliases in Name, e.g. "config, c"
+			s, _ := stringConstant(kv.Value)
+			names := strings.Split(s, ",")
+			flag.Name = strings.TrimSpace(names[0])
+			for _, alias := range names[1:] {
+				if alias = strings.TrimSpace(alias); len(alias) == 1 && flag.Shorthand == "" {
+					flag.Shorthand = alias
+				}
+			}
+		case "Aliases":
+			aliases, ok := kv.Value.(*ast.CompositeLit)
+			if !ok {
+				break
+			}
+			for _, elt := range aliases.Elts {
+				if alias, _ := stringConstant(elt); len(alias) == 1 && flag.Shorthand == "" {
+					flag.Shorthand = alias
+				}
+			}
+		case "Value":
+			if v := exprString(fset, kv.Value); !zeroValues[v] && v != "nil" {
+				flag.Default = v
+			}
+		case "Usage":
+			flag.Usage, _ = stringConstant(kv.Value)
+		}
+	}
+	if flag.Name == "" {
+		return Flag{}, false
+	}
+	return flag, true
+}
+
+// isHidden reports whether expr is a composite literal with Hidden: true.
+func isHidden(expr ast.Expr) bool {
+	if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		expr = u.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return false
+	}
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Hidden" {
+				v, ok := kv.Value.(*ast.Ident)
+				return ok && v.Name == "true"
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestCliCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected []Command
+	}{
+		{
+			"v2",
+			`package main
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+var versionCmd = &cli.Command{Name: "version", Usage: "Print the version"}
+
+func main() {
+	app := &cli.App{
+		Usage: "tool does things",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "verbose", Aliases: []string{"v"}, Usage: "verbose output"},
+			&cli.StringFlag{Name: "secret", Hidden: true},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:        "serve",
+				Usage:       "Serve files",
+				Description: "Serve files in DIR over HTTP.\n",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "addr", Aliases: []string{"listen", "a"}, Value: ":8080", Usage: "address to listen on"},
+					&cli.DurationFlag{Name: "timeout", Value: 30 * time.Second},
+				},
+				Subcommands: []*cli.Command{reloadCmd()},
+			},
+			versionCmd,
+			{Name: "debug", Hidden: true},
+		},
+	}
+	app.Run(os.Args)
+}
+
+func reloadCmd() *cli.Command {
+	return &cli.Command{Name: "reload", Usage: "Reload the server"}
+}
+`,
+			[]Command{
+				{Path: "tool", Short: "tool does things", Flags: []Flag{{Name: "verbose", Shorthand: "v", Type: "bool", Usage: "verbose output"}}},
+				{Path: "tool serve", Short: "Serve files", Long: "Serve files in DIR over HTTP.", Flags: []Flag{
+					{Name: "addr", Shorthand: "a", Type: "string", Default: `":8080"`, Usage: "address to listen on"},
+					{Name: "timeout", Type: "duration", Default: "30 * time.Second"},
+				}},
+				{Path: "tool serve reload", Short: "Reload the server"},
+				{Path: "tool version", Short: "Print the version"},
+			},
+		},
+		{
+			"v1",
+			`package main
+
+import "github.com/urfave/cli"
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "greet"
+	app.Usage = "say hello"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "lang, l", Value: "english", Usage: "language for the greeting"},
+		cli.BoolTFlag{Name: "color"},
+	}
+	app.Commands = []cli.Command{
+		{Name: "complete", Usage: "complete a task"},
+	}
+}
+`,
+			[]Command{
+				{Path: "greet", Short: "say hello", Flags: []Flag{
+					{Name: "lang", Shorthand: "l", Type: "string", Default: `"english"`, Usage: "language for the greeting"},
+					{Name: "color", Type: "bool", Default: "true"},
+				}},
+				{Path: "greet complete", Short: "complete a task"},
+			},
+		},
+		{
+			"none",
+			"package main\n\nfunc main() {}\n",
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "main.go", test.src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cliCommands(fset, []*ast.File{f}, "tool"); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: Got ---\n%+v\nExpected ---\n%+v", test.name, got, test.expected)
+		}
+	}
+}
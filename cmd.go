@@ -0,0 +1,45 @@
+package main
+
+import (
+	"go/build"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Binary is a command in the cmd directory of the package.
+type Binary struct {
+	// Name is the name of the directory of the command.
+	Name       string
+	ImportPath string
+	// Synopsis is the first sentence of the package doc of the command.
+	Synopsis string
+	// Install is the command to install it, e.g. "go install PATH@latest".
+	Install string
+}
+
+// cmdBinaries returns the commands in the subdirectories of "cmd" in dir,
+// the directory of the package importPath, with the commands to install
+// them by installCommand with goVersion and version.
+func cmdBinaries(dir, importPath, goVersion, version string) []Binary {
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "cmd"))
+	if err != nil {
+		return nil
+	}
+
+	var binaries []Binary
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") || strings.HasPrefix(e.Name(), "_") || e.Name() == "testdata" {
+			continue
+		}
+		bpkg, err := build.ImportDir(filepath.Join(dir, "cmd", e.Name()), 0)
+		if err != nil || bpkg.Name != "main" {
+			continue
+		}
+		b := Binary{Name: e.Name(), ImportPath: path.Join(importPath, "cmd", e.Name()), Synopsis: bpkg.Doc}
+		b.Install = installCommand(b.ImportPath, true, goVersion, version)
+		binaries = append(binaries, b)
+	}
+	return binaries
+}
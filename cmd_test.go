@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCmdBinaries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"cmd/foo/main.go":      "// Foo does foo things.\n// More details.\npackage main\n\nfunc main() {}\n",
+		"cmd/bar/main.go":      "package main\n\nfunc main() {}\n",
+		"cmd/internal/util.go": "package util\n",
+		"cmd/_old/main.go":     "package main\n",
+		"cmd/README.md":        "",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []Binary{
+		{Name: "bar", ImportPath: "example.com/x/cmd/bar", Install: "go install example.com/x/cmd/bar@v1.2.0"},
+		{Name: "foo", ImportPath: "example.com/x/cmd/foo", Synopsis: "Foo does foo things.", Install: "go install example.com/x/cmd/foo@v1.2.0"},
+	}
+	if got := cmdBinaries(dir, "example.com/x", "1.21", "v1.2.0"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+
+	if got := cmdBinaries(filepath.Join(dir, "cmd", "foo"), "example.com/x/cmd/foo", "", ""); got != nil {
+		t.Errorf("cmdBinaries() without cmd = %+v, expected nil", got)
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Command is a (sub)command defined with github.com/spf13/cobra or
+// github.com/urfave/cli.
+type Command struct {
+	// Path is the full command line of the command, e.g. "tool serve".
+	Path string
+	// Short and Long are the descriptions of the command.
+	Short, Long string
+	// Flags are the flags of the command, including the persistent ones
+	// defined on it.
+	Flags []Flag
+
+	children []*Command
+	isChild  bool
+}
+
+// cobraFlagTypes are the types of the flags defined by the methods of
+// pflag.FlagSet, keyed by their names without the "Var" and "P" suffixes.
+var cobraFlagTypes = map[string]string{
+	"Bool": "bool", "Int": "int", "Int64": "int64", "Uint": "uint", "Uint64": "uint64",
+	"String": "string", "Float64": "float64", "Duration": "duration",
+	"StringSlice": "strings", "StringArray": "stringArray", "IntSlice": "ints",
+	"StringToString": "stringToString", "Count": "count",
+}
+
+// cobraCommands statically analyzes the cobra commands defined in files
+// except tests and returns them in depth-first order from the root, or nil
+// if cobra is not used. Commands are recognized as &cobra.Command{...}
+// literals assigned to variables or returned from functions, linked by
+// AddCommand calls.
+func cobraCommands(fset *token.FileSet, files []*ast.File) []Command {
+	// commands by "FUNC.VAR" for local variables, "VAR" for package-level
+	// ones and "FUNC()" for the ones returned from functions
+	cmds := map[string]*Command{}
+	var order []*Command
+
+	newCommand := func(expr ast.Expr) *Command {
+		if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			expr = u.X
+		}
+		lit, ok := expr.(*ast.CompositeLit)
+		if !ok || !isSelector(lit.Type, "cobra", "Command") {
+			return nil
+		}
+		cmd := &Command{}
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			s, _ := stringConstant(kv.Value)
+			switch key.Name {
+			case "Use":
+				if f := strings.Fields(s); len(f) > 0 {
+					cmd.Path = f[0]
+				}
+			case "Short":
+				cmd.Short = s
+			case "Long":
+				cmd.Long = strings.TrimSpace(s)
+			}
+		}
+		order = append(order, cmd)
+		return cmd
+	}
+
+	var sources []*ast.File
+	for _, f := range files {
+		if !strings.HasSuffix(fset.Position(f.Package).Filename, "_test.go") {
+			sources = append(sources, f)
+		}
+	}
+	files = sources
+
+	// the package-level variables first, which may be used in any function
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if i < len(vs.Values) {
+						if cmd := newCommand(vs.Values[i]); cmd != nil {
+							cmds[name.Name] = cmd
+						}
+					}
+				}
+			}
+		}
+	}
+
+	funcs := []*ast.FuncDecl{}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil && fn.Recv == nil {
+				funcs = append(funcs, fn)
+			}
+		}
+	}
+
+	for _, fn := range funcs {
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				for i, lhs := range n.Lhs {
+					id, ok := lhs.(*ast.Ident)
+					if !ok || i >= len(n.Rhs) {
+						continue
+					}
+					if cmd := newCommand(n.Rhs[i]); cmd != nil {
+						cmds[fn.Name.Name+"."+id.Name] = cmd
+					}
+				}
+			case *ast.ReturnStmt:
+				if len(n.Results) != 1 {
+					break
+				}
+				if cmd := newCommand(n.Results[0]); cmd != nil {
+					cmds[fn.Name.Name+"()"] = cmd
+				} else if id, ok := n.Results[0].(*ast.Ident); ok {
+					if cmd := cmds[fn.Name.Name+"."+id.Name]; cmd != nil {
+						cmds[fn.Name.Name+"()"] = cmd
+					}
+				}
+			}
+			return true
+		})
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	lookup := func(fn string, expr ast.Expr) *Command {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			if cmd := cmds[fn+"."+e.Name]; cmd != nil {
+				return cmd
+			}
+			return cmds[e.Name]
+		case *ast.CallExpr:
+			if id, ok := e.Fun.(*ast.Ident); ok {
+				return cmds[id.Name+"()"]
+			}
+		}
+		return nil
+	}
+
+	for _, fn := range funcs {
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+
+			if sel.Sel.Name == "AddCommand" {
+				parent := lookup(fn.Name.Name, sel.X)
+				if parent == nil {
+					return true
+				}
+				for _, arg := range call.Args {
+					if child := lookup(fn.Name.Name, arg); child != nil && child != parent && !child.isChild {
+						child.isChild = true
+						parent.children = append(parent.children, child)
+					}
+				}
+				return true
+			}
+
+			// cmd.Flags().StringVarP(&v, "name", "n", "default", "usage")
+			flags, ok := sel.X.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			flagsSel, ok := flags.Fun.(*ast.SelectorExpr)
+			if !ok || flagsSel.Sel.Name != "Flags" && flagsSel.Sel.Name != "PersistentFlags" {
+				return true
+			}
+			cmd := lookup(fn.Name.Name, flagsSel.X)
+			if cmd == nil {
+				return true
+			}
+			if flag, ok := cobraFlag(fset, sel.Sel.Name, call.Args); ok {
+				cmd.Flags = append(cmd.Flags, flag)
+			}
+			return true
+		})
+	}
+
+	return flattenCommands(order, "")
+}
+
+// flattenCommands returns the commands in cmds which are not subcommands
+// and their subcommands in depth-first order, with the paths prefixed by
+// the ones of the parents. The root commands without names are named name.
+func flattenCommands(cmds []*Command, name string) []Command {
+	var result []Command
+	var walk func(cmd *Command, prefix string)
+	walk = func(cmd *Command, prefix string) {
+		c := *cmd
+		c.Path = strings.TrimSpace(prefix + " " + cmd.Path)
+		c.children, c.isChild = nil, false
+		result = append(result, c)
+		for _, child := range cmd.children {
+			walk(child, c.Path)
+		}
+	}
+	for _, cmd := range cmds {
+		if cmd.isChild {
+			continue
+		}
+		if cmd.Path == "" {
+			cmd.Path = name
+		}
+		walk(cmd, "")
+	}
+	return result
+}
+
+// cobraFlag parses the arguments of the pflag method named method, e.g.
+// StringVarP, into a flag.
+func cobraFlag(fset *token.FileSet, method string, args []ast.Expr) (Flag, bool) {
+	name := method
+	shorthand := strings.HasSuffix(name, "P")
+	name = strings.TrimSuffix(name, "P")
+	isVar := strings.HasSuffix(name, "Var")
+	name = strings.TrimSuffix(name, "Var")
+	typ, ok := cobraFlagTypes[name]
+	if !ok {
+		return Flag{}, false
+	}
+
+	// [p,] name, [shorthand,] value, usage
+	if isVar {
+		if len(args) == 0 {
+			return Flag{}, false
+		}
+		args = args[1:]
+	}
+	n := 3
+	if shorthand {
+		n = 4
+	}
+	if name == "Count" {
+		// Count has no default value
+		n--
+	}
+	if len(args) != n {
+		return Flag{}, false
+	}
+
+	flag := Flag{Type: typ}
+	if flag.Name, ok = stringConstant(args[0]); !ok {
+		return Flag{}, false
+	}
+	if shorthand {
+		flag.Shorthand, _ = stringConstant(args[1])
+	}
+	flag.Usage, _ = stringConstant(args[n-1])
+	if name != "Count" {
+		if v := exprString(fset, args[n-2]); !zeroValues[v] && v != "nil" && v != "[]string{}" {
+			flag.Default = v
+		}
+	}
+	return flag, true
+}
+
+// isSelector reports whether expr is pkg.name.
+func isSelector(expr ast.Expr, pkg, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == pkg
+}
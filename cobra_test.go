@@ -0,0 +1,124 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestCobraCommands(t *testing.T) {
+	src := `package main
+
+import "github.com/spf13/cobra"
+
+var rootCmd = &cobra.Command{
+	Use:   "tool",
+	Short: "tool does things",
+}
+
+var verbose int
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbose, "verbose", "v", "verbosity")
+	rootCmd.AddCommand(serveCmd(), versionCmd)
+}
+
+func serveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve [flags] DIR",
+		Short: "Serve files",
+		Long: ` + "`" + `
+Serve files in DIR over HTTP.
+` + "`" + `,
+	}
+	cmd.Flags().StringP("addr", "a", ":8080", "address to listen on")
+	cmd.Flags().Bool("tls", false, "enable TLS")
+	sub := &cobra.Command{Use: "reload", Short: "Reload the server"}
+	cmd.AddCommand(sub)
+	return cmd
+}
+
+var versionCmd = &cobra.Command{Use: "version", Short: "Print the version"}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Command{
+		{Path: "tool", Short: "tool does things", Flags: []Flag{{Name: "verbose", Shorthand: "v", Type: "count", Usage: "verbosity"}}},
+		{Path: "tool serve", Short: "Serve files", Long: "Serve files in DIR over HTTP.", Flags: []Flag{
+			{Name: "addr", Shorthand: "a", Type: "string", Default: `":8080"`, Usage: "address to listen on"},
+			{Name: "tls", Type: "bool", Usage: "enable TLS"},
+		}},
+		{Path: "tool serve reload", Short: "Reload the server"},
+		{Path: "tool version", Short: "Print the version"},
+	}
+	if got := cobraCommands(fset, []*ast.File{f}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+
+	f, err = parser.ParseFile(fset, "plain.go", "package main\n\nfunc main() {}\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cobraCommands(fset, []*ast.File{f}); got != nil {
+		t.Errorf("cobraCommands() without cobra = %+v, expected nil", got)
+	}
+}
+
+func TestCobraCommands_files(t *testing.T) {
+	files := map[string]string{
+		"main.go": `package main
+
+import "github.com/spf13/cobra"
+
+var rootCmd = &cobra.Command{Use: "tool", Short: "tool does things"}
+
+func main() { rootCmd.Execute() }
+`,
+		"version.go": `package main
+
+import "github.com/spf13/cobra"
+
+var versionCmd = &cobra.Command{Use: "version", Short: "Print the version"}
+
+func init() { rootCmd.AddCommand(versionCmd) }
+`,
+		"build.go": `package main
+
+import "github.com/spf13/cobra"
+
+var buildCmd = &cobra.Command{Use: "build", Short: "Build the site"}
+
+func init() {
+	buildCmd.Flags().Bool("drafts", false, "include drafts")
+	rootCmd.AddCommand(buildCmd)
+}
+`,
+		"serve.go": `package main
+
+import "github.com/spf13/cobra"
+
+var serveCmd = &cobra.Command{Use: "serve", Short: "Serve the site"}
+
+func init() { rootCmd.AddCommand(serveCmd) }
+`,
+	}
+
+	expected := "## Commands\n\n" +
+		"### `tool`\n\ntool does things\n\n" +
+		"### `tool build`\n\nBuild the site\n\n" +
+		"| Flag | Default | Description |\n|---|---|---|\n| `--drafts` |  | include drafts |\n\n" +
+		"### `tool serve`\n\nServe the site\n\n" +
+		"### `tool version`\n\nPrint the version\n"
+	for i := 0; i < 2; i++ {
+		got := renderSections(t, testReadme(t, files, loadOptions{}), "commands")
+		if got != expected {
+			t.Errorf("render #%d:\nGot ---\n%s\nExpected ---\n%s", i+1, got, expected)
+		}
+	}
+}
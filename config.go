@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFile is the name of the configuration file looked up in the package directory.
+const ConfigFile = ".goreadme.yml"
+
+// Config is the configuration of goreadme read from ConfigFile.
+// Command line flags take precedence over it.
+type Config struct {
+	// Sections selects the sections to generate and their order. See parseSections.
+	Sections []string `yaml:"sections"`
+	// Collapse are the sections to be collapsed in <details> blocks.
+	Collapse []string `yaml:"collapse"`
+	// CollapseOutput collapses the outputs of examples in <details> blocks.
+	CollapseOutput bool `yaml:"collapse_output"`
+	// FieldTables renders struct types as tables of their fields in the
+	// types section.
+	FieldTables bool `yaml:"field_tables"`
+	// Examples selects the examples to include by a regular expression
+	// matching their function names.
+	Examples string `yaml:"examples"`
+	// ExcludeExamples are the function names of the examples to exclude,
+	// as well as the ones marked by "//goreadme:ignore".
+	ExcludeExamples []string `yaml:"exclude_examples"`
+	// ExampleOrder is the order of examples, "name" or "source". Defaults
+	// to "name"; the examples used to be in no particular order.
+	ExampleOrder string `yaml:"example_order"`
+	// ExampleCode is how to render examples, "file" or "body".
+	ExampleCode string `yaml:"example_code"`
+	// BenchResults is the output of "go test -bench" to show in the
+	// benchmarks section, relative to the current directory.
+	BenchResults string `yaml:"bench_results"`
+	// DocSite is the URL of the documentation site the reference badge
+	// links to, e.g. a self-hosted pkgsite.
+	DocSite string `yaml:"doc_site"`
+	// DockerImage is the Docker image to run the package with, e.g.
+	// "motemen/goreadme" on Docker Hub. It is detected from the GoReleaser
+	// configuration or the Dockerfile if not set.
+	DockerImage string `yaml:"docker_image"`
+	// ConfigStruct is the name of the configuration struct of the package
+	// documented in the configuration section, instead of the one marked
+	// by "//goreadme:config".
+	ConfigStruct string `yaml:"config_struct"`
+	// Badges are additional badges appended to the detected ones.
+	Badges []Badge `yaml:"badges"`
+	// BadgeOrder are the kinds of badges in the order to show, e.g.
+	// ["release", "actions"]. The others follow them.
+	BadgeOrder []string `yaml:"badge_order"`
+	// DisableBadges are the kinds of the detected badges not to show,
+	// e.g. ["travis"].
+	DisableBadges []string `yaml:"disable_badges"`
+	// Repository is the URL of the repository of the package, e.g.
+	// "https://github.com/uber-go/zap" for go.uber.org/zap. If not set, it is
+	// derived from the import path, or resolved by fetching the go-import
+	// meta tags of vanity import paths with -resolve-vanity.
+	Repository string `yaml:"repository"`
+	// GitLabHosts are the hosts of self-hosted GitLab, e.g. ["gitlab.example.com"].
+	GitLabHosts []string `yaml:"gitlab_hosts"`
+	// GiteaHosts are the hosts of self-hosted Gitea or Forgejo.
+	GiteaHosts []string `yaml:"gitea_hosts"`
+	// Branch is the branch the badges show the status of, e.g. "main".
+	// The default is the default branch of the origin remote.
+	Branch string `yaml:"branch"`
+	// BadgeStyle is the style of the badges of shields.io, e.g. "flat-square".
+	BadgeStyle string `yaml:"badge_style"`
+	// Author overrides the author loaded from git config.
+	Author Person `yaml:"author"`
+	// Maintainers are the maintainers of the package listed instead of
+	// the author. The default is the owners of the package in CODEOWNERS.
+	Maintainers []Person `yaml:"maintainers"`
+	// ContributorsMinCommits is the minimum number of commits of
+	// the contributors listed in the contributors section.
+	ContributorsMinCommits int `yaml:"contributors_min_commits"`
+	// Homebrew is the Homebrew formula to install the command with, in the
+	// form of "owner/tap/name". It is detected from the GoReleaser
+	// configuration if not set.
+	Homebrew string `yaml:"homebrew"`
+	// ChangelogTags is the number of the latest annotated tags to show
+	// in the changelog section with their messages.
+	ChangelogTags int `yaml:"changelog_tags"`
+	// Snippets are named texts available to templates by {{snippet "NAME"}}.
+	Snippets map[string]string `yaml:"snippets"`
+	// SnippetsFile is a YAML file of shared snippets, relative to the
+	// package directory unless absolute. Snippets defined in Snippets take precedence.
+	SnippetsFile string `yaml:"snippets_file"`
+}
+
+// selfHosted returns the kinds of the self-hosted services by their hosts.
+func (c Config) selfHosted() map[string]string {
+	hosts := map[string]string{}
+	for _, host := range c.GitLabHosts {
+		hosts[host] = "gitlab"
+	}
+	for _, host := range c.GiteaHosts {
+		hosts[host] = "gitea"
+	}
+	return hosts
+}
+
+// loadConfig reads ConfigFile in dir. It is not an error if the file does not exist.
+func loadConfig(dir string) (*Config, error) {
+	var conf Config
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, ConfigFile))
+	if os.IsNotExist(err) {
+		return &conf, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.UnmarshalStrict(b, &conf); err != nil {
+		return nil, fmt.Errorf("%s: %v", ConfigFile, err)
+	}
+
+	for i, b := range conf.Badges {
+		if b.Image == "" {
+			return nil, fmt.Errorf("%s: badges[%d]: image is required", ConfigFile, i)
+		}
+		conf.Badges[i].Kind = "custom"
+	}
+
+	if conf.SnippetsFile != "" {
+		path := conf.SnippetsFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		shared := map[string]string{}
+		if err := yaml.Unmarshal(b, &shared); err != nil {
+			return nil, fmt.Errorf("%s: %v", conf.SnippetsFile, err)
+		}
+
+		for name, text := range conf.Snippets {
+			shared[name] = text
+		}
+		conf.Snippets = shared
+	}
+
+	return &conf, nil
+}
+
+// splitList splits a comma-separated flag value.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
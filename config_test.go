@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfig_badges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := `badges:
+  - name: Build
+    image: https://ci.example.com/foo/badge.svg
+    link: https://ci.example.com/foo
+  - name: Dashboard
+    image: https://dashboard.example.com/foo.svg
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, ConfigFile), []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := loadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badges := badgeMarkdowns(c.Badges)
+	expected := []string{
+		"[![Build](https://ci.example.com/foo/badge.svg)](https://ci.example.com/foo)",
+		"![Dashboard](https://dashboard.example.com/foo.svg)",
+	}
+	if !reflect.DeepEqual(badges, expected) {
+		t.Errorf("badges mismatch:\nGot ---\n%q\nExpected ---\n%q\n", badges, expected)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, ConfigFile), []byte("badges:\n  - name: Build\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(dir); err == nil {
+		t.Error("loadConfig should fail for badges without image")
+	}
+}
+
+func TestLoadConfig_snippets(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "pkg")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	shared := filepath.Join(root, "snippets.yml")
+	if err := ioutil.WriteFile(shared, []byte("support: Ask on the forum.\nfooter: Shared footer.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		conf     string
+		expected map[string]string
+	}{
+		{
+			"snippets_file: ../snippets.yml\nsnippets:\n  footer: Own footer.\n",
+			map[string]string{"support": "Ask on the forum.", "footer": "Own footer."},
+		},
+		{
+			"snippets_file: " + shared + "\n",
+			map[string]string{"support": "Ask on the forum.", "footer": "Shared footer."},
+		},
+		{
+			"snippets:\n  footer: Own footer.\n",
+			map[string]string{"footer": "Own footer."},
+		},
+	}
+	for _, test := range tests {
+		if err := ioutil.WriteFile(filepath.Join(dir, ConfigFile), []byte(test.conf), 0644); err != nil {
+			t.Fatal(err)
+		}
+		conf, err := loadConfig(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(conf.Snippets, test.expected) {
+			t.Errorf("snippets of %q:\nGot ---\n%+v\nExpected ---\n%+v", test.conf, conf.Snippets, test.expected)
+		}
+	}
+
+	// relative to the package directory, not the working directory
+	if err := ioutil.WriteFile(filepath.Join(dir, ConfigFile), []byte("snippets_file: snippets.yml\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(dir); err == nil {
+		t.Error("loadConfig should fail for a missing snippets file")
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// configDirective marks the configuration struct of the package documented
+// in the configuration section, if it is in the doc comment of the type.
+const configDirective = "//goreadme:config"
+
+// ConfigField is a field of the configuration struct of the package.
+type ConfigField struct {
+	// Key is the key of the field in configuration files by the yaml, json,
+	// toml or mapstructure tag, or the name of the field, after the keys of
+	// the enclosing structs joined by ".", e.g. "server.port".
+	Key  string
+	Type string
+	// Default is the "default" or "envDefault" tag.
+	Default string
+	// Env is the environment variable setting the field by envconfig or
+	// caarlos0/env.
+	Env         string
+	Description string
+}
+
+// configKeyTags are the tags naming the keys of fields, in order of priority.
+var configKeyTags = []string{"yaml", "json", "toml", "mapstructure"}
+
+// configFields returns the exported fields of the struct type named name
+// in files except tests, or of the one marked by configDirective if name
+// is empty, with the fields of nested structs of the package flattened.
+// It returns nil if no struct is marked.
+func configFields(fset *token.FileSet, files []*ast.File, name string) ([]ConfigField, error) {
+	var sources []*ast.File
+	for _, f := range files {
+		if !strings.HasSuffix(fset.Position(f.Package).Filename, "_test.go") {
+			sources = append(sources, f)
+		}
+	}
+
+	types := map[string]*ast.StructType{}
+	for _, f := range sources {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				types[ts.Name.Name] = st
+				doc := ts.Doc
+				if doc == nil && !gen.Lparen.IsValid() {
+					doc = gen.Doc
+				}
+				if name == "" && hasDirective(doc, configDirective) {
+					name = ts.Name.Name
+				}
+			}
+		}
+	}
+	if name == "" {
+		return nil, nil
+	}
+	st, ok := types[name]
+	if !ok {
+		return nil, withStatus(exitUsage, fmt.Errorf("config struct %q not found", name))
+	}
+
+	prefix := envconfigPrefix(sources)
+	var fields []ConfigField
+	var walk func(st *ast.StructType, keyPrefix string, seen map[*ast.StructType]bool)
+	walk = func(st *ast.StructType, keyPrefix string, seen map[*ast.StructType]bool) {
+		seen[st] = true
+		defer delete(seen, st)
+
+		// nested returns the struct type of the package of expr, if any
+		nested := func(expr ast.Expr) *ast.StructType {
+			if star, ok := expr.(*ast.StarExpr); ok {
+				expr = star.X
+			}
+			var st *ast.StructType
+			switch e := expr.(type) {
+			case *ast.StructType:
+				st = e
+			case *ast.Ident:
+				st = types[e.Name]
+			}
+			if seen[st] {
+				return nil
+			}
+			return st
+		}
+
+		for _, field := range st.Fields.List {
+			var tag reflect.StructTag
+			if field.Tag != nil {
+				if s, err := strconv.Unquote(field.Tag.Value); err == nil {
+					tag = reflect.StructTag(s)
+				}
+			}
+			_, inline, ok := configKey(tag, "")
+			if !ok {
+				continue
+			}
+
+			if len(field.Names) == 0 || inline {
+				// embedded or inlined fields are flattened into the parent
+				if st := nested(field.Type); st != nil {
+					walk(st, keyPrefix, seen)
+				}
+				continue
+			}
+
+			for _, id := range field.Names {
+				if !ast.IsExported(id.Name) {
+					continue
+				}
+				k, _, _ := configKey(tag, id.Name)
+				if st := nested(field.Type); st != nil {
+					walk(st, keyPrefix+k+".", seen)
+					continue
+				}
+
+				f := ConfigField{
+					Key:         keyPrefix + k,
+					Type:        exprString(fset, field.Type),
+					Default:     tag.Get("default"),
+					Description: fieldDescription(field, tag),
+				}
+				if env, ok := fieldEnv(tag, prefix); ok {
+					f.Env = env.Name
+					if f.Default == "" {
+						f.Default = env.Default
+					}
+				}
+				fields = append(fields, f)
+			}
+		}
+	}
+	walk(st, "", map[*ast.StructType]bool{})
+
+	return fields, nil
+}
+
+// configKey returns the key of the field named name by the first of
+// configKeyTags in tag, and whether the field is inlined. It returns false
+// if the field is ignored by "-".
+func configKey(tag reflect.StructTag, name string) (key string, inline, ok bool) {
+	for _, t := range configKeyTags {
+		v, found := tag.Lookup(t)
+		if !found {
+			continue
+		}
+		if v == "-" {
+			return "", false, false
+		}
+		opts := strings.Split(v, ",")
+		for _, opt := range opts[1:] {
+			inline = inline || opt == "inline" || opt == "squash"
+		}
+		key = opts[0]
+		if key == "" && t == "yaml" {
+			// yaml lowercases the names by default
+			key = strings.ToLower(name)
+		}
+		if key == "" {
+			key = name
+		}
+		return key, inline, true
+	}
+	return name, false, true
+}
+
+// hasDirective reports whether the comment group contains directive on
+// a line.
+func hasDirective(doc *ast.CommentGroup, directive string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == directive {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestConfigFields(t *testing.T) {
+	src := `package main
+
+type (
+	// Config is the configuration of the server.
+	//goreadme:config
+	Config struct {
+		// Listen is the address to listen on.
+		Listen  string        ` + "`yaml:\"listen\" envconfig:\"LISTEN\" default:\":8080\"`" + `
+		Timeout time.Duration ` + "`yaml:\",omitempty\"`" + ` // timeout of requests
+		DB      *Database
+		Log     struct {
+			Level string ` + "`yaml:\"level\" env:\"LOG_LEVEL\" envDefault:\"info\"`" + `
+		}
+		Common ` + "`yaml:\",inline\"`" + `
+		Secret string ` + "`yaml:\"-\"`" + `
+		cache  bool
+	}
+
+	Database struct {
+		URL string ` + "`json:\"url\" desc:\"URL of the database\"`" + `
+	}
+)
+
+type Common struct {
+	Verbose bool
+	Parent  *Config
+}
+
+type Other struct {
+	Name string
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "config.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ConfigField{
+		{Key: "listen", Type: "string", Default: ":8080", Env: "LISTEN", Description: "Listen is the address to listen on."},
+		{Key: "timeout", Type: "time.Duration", Description: "timeout of requests"},
+		{Key: "DB.url", Type: "string", Description: "URL of the database"},
+		{Key: "Log.level", Type: "string", Default: "info", Env: "LOG_LEVEL"},
+		{Key: "Verbose", Type: "bool"},
+		{Key: "Parent", Type: "*Config"},
+	}
+	got, err := configFields(fset, []*ast.File{f}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+
+	got, err = configFields(fset, []*ast.File{f}, "Other")
+	if expected := []ConfigField{{Key: "Name", Type: "string"}}; err != nil || !reflect.DeepEqual(got, expected) {
+		t.Errorf("configFields(Other) = %+v, %v, expected %+v", got, err, expected)
+	}
+
+	if _, err := configFields(fset, []*ast.File{f}, "Unknown"); err == nil {
+		t.Errorf("configFields(Unknown) should fail")
+	}
+}
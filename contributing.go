@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+)
+
+// contributingFileNames are the locations of the contributing guidelines,
+// in the order GitHub looks them up.
+var contributingFileNames = []string{
+	filepath.Join(".github", "CONTRIBUTING.md"),
+	"CONTRIBUTING.md",
+	filepath.Join("docs", "CONTRIBUTING.md"),
+	"CONTRIBUTING",
+	"CONTRIBUTING.txt",
+	"CONTRIBUTING.rst",
+}
+
+// codeOfConductFileNames are the locations of the code of conduct.
+var codeOfConductFileNames = []string{
+	filepath.Join(".github", "CODE_OF_CONDUCT.md"),
+	"CODE_OF_CONDUCT.md",
+	filepath.Join("docs", "CODE_OF_CONDUCT.md"),
+}
+
+// changelogFileNames are the names of the changelog.
+var changelogFileNames = []string{"CHANGELOG.md", "CHANGES.md", "CHANGELOG", "CHANGES", "HISTORY.md", "NEWS.md"}
+
+// detectCommunityFile finds the first of the files such as the contributing
+// guidelines in dir or its parent directories up to the repository root,
+// and returns its path relative to dir, or "" if there is none.
+func detectCommunityFile(dir string, names ...string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	path := findFileUp(dir, names...)
+	if path == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
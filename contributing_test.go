@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectCommunityFile(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "sub")
+	for _, d := range []string{dir, filepath.Join(root, ".git"), filepath.Join(root, ".github")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := detectCommunityFile(dir, contributingFileNames...); got != "" {
+		t.Errorf("detectCommunityFile() = %q, expected none", got)
+	}
+
+	for _, test := range []struct {
+		file     string
+		expected string
+	}{
+		{"CONTRIBUTING.md", "../CONTRIBUTING.md"},
+		{".github/CONTRIBUTING.md", "../.github/CONTRIBUTING.md"},
+		{"sub/CONTRIBUTING.md", "CONTRIBUTING.md"},
+	} {
+		if err := ioutil.WriteFile(filepath.Join(root, test.file), []byte("# Contributing\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := detectCommunityFile(dir, contributingFileNames...); got != test.expected {
+			t.Errorf("detectCommunityFile() with %s = %q, expected %q", test.file, got, test.expected)
+		}
+	}
+
+	if got := detectCommunityFile(dir, codeOfConductFileNames...); got != "" {
+		t.Errorf("detectCommunityFile() = %q, expected none", got)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, ".github", "CODE_OF_CONDUCT.md"), []byte("# Code of Conduct\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := detectCommunityFile(dir, codeOfConductFileNames...), "../.github/CODE_OF_CONDUCT.md"; got != expected {
+		t.Errorf("detectCommunityFile() = %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// githubAPIURL is the endpoint of the GitHub REST API.
+var githubAPIURL = "https://api.github.com"
+
+// avatarSize is the size in pixels of the avatars in the contributors section.
+const avatarSize = 64
+
+// loadContributors returns the contributors to the package in dir with at
+// least minCommits commits. If GITHUB_TOKEN is set and the repository is
+// on GitHub, they are the contributors to the whole repository with their
+// avatars from the GitHub API.
+func loadContributors(dir string, repo *Repository, minCommits int) ([]Contributor, error) {
+	var (
+		contributors []Contributor
+		err          error
+	)
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && repo != nil && repo.Kind == "github" && repo.Host == "github.com" {
+		contributors, err = githubContributors(repo.Path, token)
+	} else {
+		contributors, err = shortlog(dir, ".")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []Contributor
+	for _, c := range contributors {
+		if c.Commits >= minCommits {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, nil
+}
+
+// githubContributors returns the contributors to the GitHub repository
+// "owner/name" in descending order of the number of commits.
+func githubContributors(path, token string) ([]Contributor, error) {
+	req, err := http.NewRequest("GET", githubAPIURL+"/repos/"+path+"/contributors?per_page=100", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, withStatus(exitVCSOrNetwork, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, withStatus(exitVCSOrNetwork, fmt.Errorf("fetching contributors of %s: %s", path, resp.Status))
+	}
+
+	var users []struct {
+		Login         string `json:"login"`
+		Type          string `json:"type"`
+		HTMLURL       string `json:"html_url"`
+		AvatarURL     string `json:"avatar_url"`
+		Contributions int    `json:"contributions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+		return nil, withStatus(exitVCSOrNetwork, fmt.Errorf("fetching contributors of %s: %v", path, err))
+	}
+
+	var contributors []Contributor
+	for _, u := range users {
+		if u.Type == "Bot" {
+			continue
+		}
+		contributors = append(contributors, Contributor{
+			Person:  Person{Name: u.Login, URL: u.HTMLURL},
+			Commits: u.Contributions,
+			Avatar:  avatarURL(u.AvatarURL, avatarSize),
+		})
+	}
+	return contributors, nil
+}
+
+// avatarURL returns the URL of the GitHub avatar image resized to size pixels.
+func avatarURL(s string, size int) string {
+	u, err := url.Parse(s)
+	if err != nil || s == "" {
+		return s
+	}
+	q := u.Query()
+	q.Set("s", fmt.Sprint(size))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestLoadContributors_github(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/repos/motemen/goreadme/contributors" || req.Header.Get("Authorization") != "Bearer TOKEN" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write([]byte(`[
+  {"login": "motemen", "type": "User", "html_url": "https://github.com/motemen", "avatar_url": "https://avatars.githubusercontent.com/u/1?v=4", "contributions": 120},
+  {"login": "dependabot[bot]", "type": "Bot", "html_url": "https://github.com/apps/dependabot", "avatar_url": "https://avatars.githubusercontent.com/in/29110?v=4", "contributions": 30},
+  {"login": "alice", "type": "User", "html_url": "https://github.com/alice", "avatar_url": "https://avatars.githubusercontent.com/u/2?v=4", "contributions": 3},
+  {"login": "bob", "type": "User", "html_url": "https://github.com/bob", "avatar_url": "https://avatars.githubusercontent.com/u/3?v=4", "contributions": 1}
+]`))
+	}))
+	defer ts.Close()
+
+	defer func(url string) { githubAPIURL = url }(githubAPIURL)
+	githubAPIURL = ts.URL
+
+	defer os.Setenv("GITHUB_TOKEN", os.Getenv("GITHUB_TOKEN"))
+	os.Setenv("GITHUB_TOKEN", "TOKEN")
+
+	repo := &Repository{Kind: "github", Host: "github.com", Path: "motemen/goreadme"}
+	got, err := loadContributors(".", repo, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Contributor{
+		{Person: Person{Name: "motemen", URL: "https://github.com/motemen"}, Commits: 120, Avatar: "https://avatars.githubusercontent.com/u/1?s=64&v=4"},
+		{Person: Person{Name: "alice", URL: "https://github.com/alice"}, Commits: 3, Avatar: "https://avatars.githubusercontent.com/u/2?s=64&v=4"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+
+	repo.Path = "motemen/unknown"
+	if _, err := loadContributors(".", repo, 0); err == nil {
+		t.Errorf("loadContributors should fail for unknown repository")
+	}
+}
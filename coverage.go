@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var rxCoverage = regexp.MustCompile(`coverage: ([0-9.]+)% of statements`)
+
+// runCoverage runs "go test -cover" for the package in dir and returns
+// the coverage in percent.
+func runCoverage(dir string) (float64, error) {
+	cmd := exec.Command("go", "test", "-cover", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("go test -cover: %v\n%s", err, out)
+	}
+
+	m := rxCoverage.FindSubmatch(out)
+	if m == nil {
+		return 0, fmt.Errorf("go test -cover: coverage not reported:\n%s", out)
+	}
+
+	return strconv.ParseFloat(string(m[1]), 64)
+}
+
+// readCoverProfile computes the coverage in percent from the profile
+// written by "go test -coverprofile". Blocks appearing more than once,
+// as in merged profiles, are counted as covered if any of them is.
+func readCoverProfile(file string) (float64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	type block struct {
+		stmts   int
+		covered bool
+	}
+	blocks := map[string]*block{}
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "mode:") || line == "" {
+			continue
+		}
+
+		// file:start.col,end.col numStmts count
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return 0, fmt.Errorf("%s: invalid line: %q", file, line)
+		}
+		stmts, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid line: %q", file, line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid line: %q", file, line)
+		}
+
+		b, ok := blocks[fields[0]]
+		if !ok {
+			b = &block{stmts: stmts}
+			blocks[fields[0]] = b
+		}
+		b.covered = b.covered || count > 0
+	}
+	if err := s.Err(); err != nil {
+		return 0, err
+	}
+
+	var total, covered int
+	for _, b := range blocks {
+		total += b.stmts
+		if b.covered {
+			covered += b.stmts
+		}
+	}
+	if total == 0 {
+		return 0, nil
+	}
+
+	return float64(covered) * 100 / float64(total), nil
+}
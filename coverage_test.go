@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCoverProfile(t *testing.T) {
+	profile := `mode: set
+github.com/motemen/goreadme/a.go:1.1,3.2 3 1
+github.com/motemen/goreadme/a.go:4.1,6.2 1 0
+github.com/motemen/goreadme/b.go:1.1,2.2 4 0
+github.com/motemen/goreadme/b.go:1.1,2.2 4 1
+github.com/motemen/goreadme/b.go:3.1,4.2 2 0
+`
+
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "cover.out")
+	if err := ioutil.WriteFile(file, []byte(profile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	coverage, err := readCoverProfile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := 70.0; coverage != expected {
+		t.Errorf("readCoverProfile = %v, expected %v", coverage, expected)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// detectDockerImage returns the image presumably published from the Dockerfile
+// of the package in dir or at the root of its repository repo, that is,
+// "ghcr.io/OWNER/REPO" for GitHub repositories. It returns "" if there is
+// no Dockerfile or the image is not known.
+func detectDockerImage(dir string, repo *Repository) string {
+	if repo == nil || repo.Kind != "github" {
+		return ""
+	}
+
+	dirs := []string{dir}
+	if root := repoRoot(dir); root != "" {
+		dirs = append(dirs, root)
+	}
+	for _, d := range dirs {
+		if _, err := os.Stat(filepath.Join(d, "Dockerfile")); err == nil {
+			// image names are lowercase
+			return "ghcr.io/" + strings.ToLower(repo.Path)
+		}
+	}
+
+	return ""
+}
+
+// dockerBadges returns the badges of the Docker image, pulls on Docker Hub
+// or a link to the package on GitHub Container Registry. Images on other
+// registries, or no image, have no badges.
+func dockerBadges(image string) []Badge {
+	if image == "" {
+		return nil
+	}
+
+	name := image
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		// drop the tag
+		name = name[:i]
+	}
+
+	parts := strings.Split(name, "/")
+	switch {
+	case parts[0] == "ghcr.io" && len(parts) >= 3:
+		path := strings.Join(parts[1:], "/")
+		return []Badge{{
+			Kind:  "docker",
+			Name:  "Container",
+			Image: "https://img.shields.io/badge/ghcr.io-" + shieldsEscape(path) + "-blue.svg?logo=docker",
+			Link:  "https://github.com/" + parts[1] + "/" + parts[2] + "/pkgs/container/" + strings.Join(parts[2:], "%2F"),
+		}}
+	case parts[0] == "docker.io" && len(parts) >= 2:
+		parts = parts[1:]
+		fallthrough
+	case !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost":
+		if len(parts) == 1 {
+			// official images
+			parts = []string{"library", parts[0]}
+		}
+		path := strings.Join(parts, "/")
+		link := "https://hub.docker.com/r/" + path
+		if parts[0] == "library" {
+			link = "https://hub.docker.com/_/" + parts[1]
+		}
+		return []Badge{{
+			Kind:  "docker",
+			Name:  "Docker Pulls",
+			Image: "https://img.shields.io/docker/pulls/" + path + ".svg",
+			Link:  link,
+		}}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectDockerImage(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "cmd", "foo")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if image := detectDockerImage(dir, resolveRepository("github.com/Motemen/foo/cmd/foo", nil)); image != "" {
+		t.Errorf("detectDockerImage should be empty without Dockerfile: %q", image)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(root, "Dockerfile"), []byte("FROM scratch\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "ghcr.io/motemen/foo"
+	if image := detectDockerImage(dir, resolveRepository("github.com/Motemen/foo/cmd/foo", nil)); image != expected {
+		t.Errorf("detectDockerImage() = %q, expected %q", image, expected)
+	}
+}
+
+func TestDockerBadges(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected []string
+	}{
+		{
+			"ghcr.io/motemen/go-foo:latest",
+			[]string{"[![Container](https://img.shields.io/badge/ghcr.io-motemen%2Fgo--foo-blue.svg?logo=docker)](https://github.com/motemen/go-foo/pkgs/container/go-foo)"},
+		},
+		{
+			"motemen/foo",
+			[]string{"[![Docker Pulls](https://img.shields.io/docker/pulls/motemen/foo.svg)](https://hub.docker.com/r/motemen/foo)"},
+		},
+		{
+			"docker.io/golang:1.21",
+			[]string{"[![Docker Pulls](https://img.shields.io/docker/pulls/library/golang.svg)](https://hub.docker.com/_/golang)"},
+		},
+		{
+			"registry.example.com/motemen/foo",
+			nil,
+		},
+		{
+			"",
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		if got := badgeMarkdowns(dockerBadges(test.image)); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("dockerBadges(%q) = %q, expected %q", test.image, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvVar is an environment variable read by the package.
+type EnvVar struct {
+	Name string
+	// Description is the comment on the line of the call reading the
+	// variable or just above it, or the doc comment of the struct field
+	// configured by it.
+	Description string
+	// Default and Required are from the struct tags of envconfig.
+	Default  string
+	Required bool
+}
+
+// envVars returns the environment variables read in the files except tests,
+// sorted by name. They are the ones read by os.Getenv or os.LookupEnv with
+// literal names, and the ones bound to struct fields by the "envconfig"
+// tags of github.com/kelseyhightower/envconfig or the "env" tags of
+// github.com/caarlos0/env.
+func envVars(fset *token.FileSet, files []*ast.File) []EnvVar {
+	var vars []EnvVar
+	index := map[string]int{}
+	add := func(v EnvVar) {
+		if v.Name == "" {
+			return
+		}
+		if i, ok := index[v.Name]; ok {
+			if vars[i].Description == "" {
+				vars[i].Description = v.Description
+			}
+			return
+		}
+		index[v.Name] = len(vars)
+		vars = append(vars, v)
+	}
+
+	var sources []*ast.File
+	for _, f := range files {
+		if !strings.HasSuffix(fset.Position(f.Package).Filename, "_test.go") {
+			sources = append(sources, f)
+		}
+	}
+
+	prefix := envconfigPrefix(sources)
+
+	for _, f := range sources {
+		comments := lineComments(fset, f)
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				if len(n.Args) != 1 || !isSelector(n.Fun, "os", "Getenv") && !isSelector(n.Fun, "os", "LookupEnv") {
+					return true
+				}
+				lit, ok := n.Args[0].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return true
+				}
+				if name, err := strconv.Unquote(lit.Value); err == nil {
+					add(EnvVar{Name: name, Description: comments.near(fset.Position(n.Pos()).Line)})
+				}
+			case *ast.Field:
+				if n.Tag == nil {
+					return true
+				}
+				s, err := strconv.Unquote(n.Tag.Value)
+				if err != nil {
+					return true
+				}
+				tag := reflect.StructTag(s)
+				v, ok := fieldEnv(tag, prefix)
+				if !ok {
+					return true
+				}
+				v.Description = fieldDescription(n, tag)
+				add(v)
+			}
+			return true
+		})
+	}
+
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars
+}
+
+// envconfigPrefix returns the prefix of the environment variables given to
+// envconfig.Process in files, e.g. "MYAPP_" for "myapp".
+func envconfigPrefix(files []*ast.File) string {
+	var prefix string
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok && isSelector(call.Fun, "envconfig", "Process") && len(call.Args) == 2 {
+				if s, ok := stringConstant(call.Args[0]); ok && s != "" {
+					prefix = strings.ToUpper(s) + "_"
+				}
+			}
+			return true
+		})
+	}
+	return prefix
+}
+
+// fieldEnv returns the environment variable bound to the struct field with
+// tag by envconfig, with prefix, or caarlos0/env.
+func fieldEnv(tag reflect.StructTag, prefix string) (EnvVar, bool) {
+	var v EnvVar
+	if name := tag.Get("envconfig"); name != "" {
+		v.Name = prefix + name
+		v.Default = tag.Get("default")
+		v.Required = tag.Get("required") == "true"
+	} else if name := tag.Get("env"); name != "" {
+		// caarlos0/env: `env:"PORT,required"`
+		opts := strings.Split(name, ",")
+		v.Name = opts[0]
+		v.Default = tag.Get("envDefault")
+		for _, opt := range opts[1:] {
+			v.Required = v.Required || opt == "required" || opt == "notEmpty"
+		}
+	}
+	return v, v.Name != ""
+}
+
+// fieldDescription returns the "desc" tag of the struct field, or its doc
+// or line comment joined into a line.
+func fieldDescription(field *ast.Field, tag reflect.StructTag) string {
+	if desc := strings.TrimSpace(tag.Get("desc")); desc != "" {
+		return desc
+	}
+	for _, g := range []*ast.CommentGroup{field.Doc, field.Comment} {
+		if g != nil {
+			return strings.Join(strings.Fields(g.Text()), " ")
+		}
+	}
+	return ""
+}
+
+// commentLines are the comment groups of a file following code on the
+// lines they start, and the other ones by the lines they end.
+type commentLines struct {
+	trailing, end map[int]*ast.CommentGroup
+}
+
+func lineComments(fset *token.FileSet, f *ast.File) commentLines {
+	// the end of the code on each line
+	code := map[int]token.Pos{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+		if line := fset.Position(n.End()).Line; n.End() > code[line] {
+			code[line] = n.End()
+		}
+		return true
+	})
+
+	c := commentLines{trailing: map[int]*ast.CommentGroup{}, end: map[int]*ast.CommentGroup{}}
+	for _, g := range f.Comments {
+		if line := fset.Position(g.Pos()).Line; code[line].IsValid() && code[line] <= g.Pos() {
+			c.trailing[line] = g
+		} else {
+			c.end[fset.Position(g.End()).Line] = g
+		}
+	}
+	return c
+}
+
+// near returns the text of the comment following the code on line, or the
+// one just above it, joined into a line.
+func (c commentLines) near(line int) string {
+	g := c.trailing[line]
+	if g == nil {
+		g = c.end[line-1]
+	}
+	if g == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(g.Text()), " ")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestEnvVars(t *testing.T) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for name, src := range map[string]string{
+		"main.go": `package main
+
+import "os"
+
+const envDebug = "DEBUG"
+
+func main() {
+	// API_TOKEN is the token to call the API with.
+	token := os.Getenv("API_TOKEN")
+	if _, ok := os.LookupEnv(envDebug); ok {
+	}
+	_, _ = os.LookupEnv("NO_COLOR") // disables colored output
+
+	_ = os.Getenv("API_TOKEN")
+}
+`,
+		"config.go": `package main
+
+import "github.com/kelseyhightower/envconfig"
+
+type config struct {
+	// Port is the port to listen on.
+	Port    int    ` + "`envconfig:\"PORT\" default:\"8080\"`" + `
+	DB      string ` + "`envconfig:\"DATABASE_URL\" required:\"true\" desc:\"URL of the database\"`" + `
+	Verbose bool
+}
+
+func load() (c config, err error) {
+	err = envconfig.Process("myapp", &c)
+	return
+}
+`,
+		"env.go": `package main
+
+type options struct {
+	Home string ` + "`env:\"HOME,required\"`" + ` // home directory
+	Tmp  string ` + "`env:\"TMPDIR\" envDefault:\"/tmp\"`" + `
+}
+`,
+		"main_test.go": `package main
+
+import "os"
+
+func init() { os.Getenv("TEST_ONLY") }
+`,
+	} {
+		f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	expected := []EnvVar{
+		{Name: "API_TOKEN", Description: "API_TOKEN is the token to call the API with."},
+		{Name: "HOME", Description: "home directory", Required: true},
+		{Name: "MYAPP_DATABASE_URL", Description: "URL of the database", Required: true},
+		{Name: "MYAPP_PORT", Description: "Port is the port to listen on.", Default: "8080"},
+		{Name: "NO_COLOR", Description: "disables colored output"},
+		{Name: "TMPDIR", Default: "/tmp"},
+	}
+	if got := envVars(fset, files); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// readmeFile is the file name matched against .gitattributes and
+// .editorconfig to find the line ending of the output.
+const readmeFile = "README.md"
+
+// lineEnding returns the line ending of the output for the package in dir,
+// which is eol if it is "lf" or "crlf". If eol is "auto", the one configured
+// for README.md by .gitattributes or .editorconfig in dir or its parent
+// directories is used, defaulting to LF.
+func lineEnding(dir, eol string) (string, error) {
+	switch eol {
+	case "lf":
+		return "\n", nil
+	case "crlf":
+		return "\r\n", nil
+	case "auto":
+	default:
+		return "", withStatus(exitUsage, fmt.Errorf("invalid -eol: %q", eol))
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if eol := gitattributesEOL(filepath.Join(dir, ".gitattributes")); eol != "" {
+			return eol, nil
+		}
+
+		eol, root := editorconfigEOL(filepath.Join(dir, ".editorconfig"))
+		if eol != "" {
+			return eol, nil
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || root {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return "\n", nil
+}
+
+// gitattributesEOL returns the line ending set by the "eol" attribute
+// for README.md in the .gitattributes file, or "" if not set.
+func gitattributesEOL(file string) string {
+	var eol string
+
+	eachLine(file, func(line string) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			return
+		}
+		if ok, _ := path.Match(strings.TrimPrefix(fields[0], "/"), readmeFile); !ok {
+			return
+		}
+		// later lines take precedence
+		for _, attr := range fields[1:] {
+			switch attr {
+			case "eol=crlf":
+				eol = "\r\n"
+			case "eol=lf":
+				eol = "\n"
+			}
+		}
+	})
+
+	return eol
+}
+
+// editorconfigEOL returns the line ending set by "end_of_line" for README.md
+// in the .editorconfig file, or "" if not set. root reports whether the
+// file has "root = true", which stops looking up parent directories.
+func editorconfigEOL(file string) (eol string, root bool) {
+	matched := false
+
+	eachLine(file, func(line string) {
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			return
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			matched = editorconfigMatch(line[1:len(line)-1], readmeFile)
+			return
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		value := strings.ToLower(strings.TrimSpace(kv[1]))
+
+		switch {
+		case key == "root":
+			root = value == "true"
+		case key == "end_of_line" && matched:
+			switch value {
+			case "crlf":
+				eol = "\r\n"
+			case "lf":
+				eol = "\n"
+			}
+		}
+	})
+
+	return eol, root
+}
+
+// editorconfigMatch reports whether name matches the section glob of
+// .editorconfig. Only a brace expansion like "*.{md,markdown}" is supported
+// in addition to the syntax of path.Match.
+func editorconfigMatch(glob, name string) bool {
+	glob = strings.TrimPrefix(glob, "/")
+	if strings.HasPrefix(glob, "**/") {
+		glob = glob[3:]
+	}
+
+	i := strings.Index(glob, "{")
+	j := strings.Index(glob, "}")
+	if i == -1 || j < i {
+		ok, _ := path.Match(glob, name)
+		return ok
+	}
+
+	for _, alt := range strings.Split(glob[i+1:j], ",") {
+		if ok, _ := path.Match(glob[:i]+alt+glob[j+1:], name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// eachLine calls fn with each trimmed line of file. Errors are ignored
+// as the file is optional.
+func eachLine(file string, fn func(line string)) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fn(strings.TrimSpace(s.Text()))
+	}
+}
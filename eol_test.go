@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLineEnding(t *testing.T) {
+	cases := []struct {
+		name               string
+		gitattributes      string
+		editorconfig       string
+		parentEditorconfig string
+		eol                string
+	}{
+		{name: "none", eol: "\n"},
+		{name: "gitattributes", gitattributes: "* text=auto\n*.md text eol=crlf\n", eol: "\r\n"},
+		{name: "gitattributes-later", gitattributes: "* eol=crlf\nREADME.md eol=lf\n", eol: "\n"},
+		{name: "gitattributes-other", gitattributes: "*.go eol=crlf\n", eol: "\n"},
+		{name: "editorconfig", editorconfig: "root = true\n\n[*.{md,markdown}]\nend_of_line = crlf\n", eol: "\r\n"},
+		{name: "editorconfig-other", editorconfig: "[*.go]\nend_of_line = crlf\n", eol: "\n"},
+		{name: "editorconfig-parent", parentEditorconfig: "[*]\nend_of_line = crlf\n", eol: "\r\n"},
+		{name: "editorconfig-root", editorconfig: "root = true\n", parentEditorconfig: "[*]\nend_of_line = crlf\n", eol: "\n"},
+	}
+
+	for _, c := range cases {
+		tmp, err := ioutil.TempDir("", "goreadme")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmp)
+
+		dir := filepath.Join(tmp, "pkg")
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		files := map[string]string{
+			filepath.Join(dir, ".gitattributes"): c.gitattributes,
+			filepath.Join(dir, ".editorconfig"):  c.editorconfig,
+			filepath.Join(tmp, ".editorconfig"):  c.parentEditorconfig,
+		}
+		for file, content := range files {
+			if content == "" {
+				continue
+			}
+			if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// stop looking up the directories above
+		if err := os.Mkdir(filepath.Join(tmp, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		eol, err := lineEnding(dir, "auto")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if eol != c.eol {
+			t.Errorf("%s: lineEnding = %q, expected %q", c.name, eol, c.eol)
+		}
+	}
+
+	if _, err := lineEnding(".", "cr"); err == nil {
+		t.Errorf("lineEnding should fail for invalid -eol")
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+// Exit statuses of goreadme.
+const (
+	exitOK           = 0
+	exitFailure      = 1
+	exitUsage        = 2
+	exitParseError   = 3
+	exitTemplate     = 4
+	exitVCSOrNetwork = 5
+)
+
+var exitKinds = map[int]string{
+	exitFailure:      "failure",
+	exitUsage:        "usage",
+	exitParseError:   "parse",
+	exitTemplate:     "template",
+	exitVCSOrNetwork: "vcs",
+}
+
+// errorFormat is the value of -error-format, either "text" or "json".
+var errorFormat = "text"
+
+// exitError is an error with the exit status it causes.
+type exitError struct {
+	status int
+	err    error
+	// reported is true if the error has already been printed (by package flag).
+	reported bool
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withStatus annotates err with the exit status. It returns nil if err is nil.
+// An exit status already attached to err is kept.
+func withStatus(status int, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *exitError
+	if errors.As(err, &e) {
+		return err
+	}
+
+	return &exitError{status: status, err: err}
+}
+
+// parseFlags parses args with flags, adding the common -error-format flag.
+// With -error-format json, the errors are not printed by package flag but
+// only as JSON by exit.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	flags.StringVar(&errorFormat, "error-format", "text", "format of error messages: text or json")
+
+	output := flags.Output()
+	if jsonErrors(args) {
+		flags.SetOutput(ioutil.Discard)
+	}
+
+	err := flags.Parse(args)
+	if err == flag.ErrHelp {
+		if jsonErrors(args) {
+			// the help is not an error
+			flags.SetOutput(output)
+			flags.Usage()
+		}
+		return &exitError{status: exitOK, err: err, reported: true}
+	} else if err != nil {
+		if jsonErrors(args) {
+			// -error-format may not have been parsed before the error
+			errorFormat = "json"
+		}
+		return &exitError{status: exitUsage, err: err, reported: true}
+	}
+
+	if errorFormat != "text" && errorFormat != "json" {
+		err := fmt.Errorf("invalid -error-format: %q", errorFormat)
+		errorFormat = "text"
+		return withStatus(exitUsage, err)
+	}
+
+	return nil
+}
+
+// jsonErrors reports whether args contain -error-format json, so that
+// the errors in parsing the other flags are reported as JSON.
+func jsonErrors(args []string) bool {
+	for i, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == "error-format=json" || name == "error-format" && i+1 < len(args) && args[i+1] == "json" {
+			return true
+		}
+	}
+	return false
+}
+
+// exit reports err in the format specified by -error-format and exits
+// with the status attached to it.
+func exit(err error) {
+	status, reported := exitFailure, false
+
+	var e *exitError
+	if errors.As(err, &e) {
+		status, reported = e.status, e.reported
+	}
+
+	if status == exitOK {
+		os.Exit(status)
+	}
+
+	if errorFormat == "json" {
+		json.NewEncoder(os.Stderr).Encode(struct {
+			Error  string `json:"error"`
+			Status int    `json:"status"`
+			Kind   string `json:"kind"`
+		}{
+			Error:  err.Error(),
+			Status: status,
+			Kind:   exitKinds[status],
+		})
+	} else if !reported {
+		log.Print(err)
+	}
+
+	os.Exit(status)
+}
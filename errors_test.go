@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestWithStatus(t *testing.T) {
+	if err := withStatus(exitUsage, nil); err != nil {
+		t.Errorf("withStatus(nil) = %v, expected nil", err)
+	}
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{withStatus(exitUsage, fmt.Errorf("foo")), exitUsage},
+		// the first status is kept
+		{withStatus(exitTemplate, withStatus(exitParseError, fmt.Errorf("foo"))), exitParseError},
+		{withStatus(exitTemplate, fmt.Errorf("wrapped: %w", withStatus(exitVCSOrNetwork, fmt.Errorf("foo")))), exitVCSOrNetwork},
+	}
+	for _, test := range tests {
+		var e *exitError
+		if !errors.As(test.err, &e) || e.status != test.status {
+			t.Errorf("status of %v = %+v, expected %d", test.err, e, test.status)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	defer func() { errorFormat = "text" }()
+
+	tests := []struct {
+		args   []string
+		status int
+		format string
+		output bool
+	}{
+		{[]string{"-foo", "x"}, exitOK, "text", false},
+		{[]string{"-h"}, exitOK, "text", true},
+		{[]string{"-error-format", "json", "-h"}, exitOK, "json", true},
+		{[]string{"-unknown"}, exitUsage, "text", true},
+		{[]string{"-unknown", "-error-format=json"}, exitUsage, "json", false},
+		{[]string{"-error-format", "json", "-unknown"}, exitUsage, "json", false},
+		{[]string{"-error-format", "xml"}, exitUsage, "text", false},
+		{[]string{"--", "-error-format=json", "-unknown"}, exitOK, "text", false},
+	}
+	for _, test := range tests {
+		errorFormat = "text"
+
+		var buf bytes.Buffer
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(&buf)
+		flags.String("foo", "", "foo")
+
+		err := parseFlags(flags, test.args)
+		status := exitOK
+		var e *exitError
+		if errors.As(err, &e) {
+			status = e.status
+		} else if err != nil {
+			status = exitFailure
+		}
+		if status != test.status {
+			t.Errorf("parseFlags(%q) = %v, expected status %d", test.args, err, test.status)
+		}
+		if errorFormat != test.format {
+			t.Errorf("parseFlags(%q): errorFormat = %q, expected %q", test.args, errorFormat, test.format)
+		}
+		if got := buf.Len() > 0; got != test.output {
+			t.Errorf("parseFlags(%q) printed %q, expected output: %v", test.args, buf.String(), test.output)
+		}
+	}
+}
+
+func TestParseDir_badRef(t *testing.T) {
+	dir := gitPackage(t, "package foo\n")
+	defer os.RemoveAll(dir)
+
+	notRepo, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(notRepo)
+
+	tests := []struct {
+		dir, ref string
+		status   int
+	}{
+		{dir, "v1", exitOK},
+		{dir, "no-such-ref", exitUsage},
+		{notRepo, "HEAD", exitVCSOrNetwork},
+	}
+	for _, test := range tests {
+		_, err := parseDir(token.NewFileSet(), test.dir, test.ref, nil)
+		status := exitOK
+		var e *exitError
+		if errors.As(err, &e) {
+			status = e.status
+		}
+		if status != test.status {
+			t.Errorf("parseDir(%q) = %v, expected status %d", test.ref, err, test.status)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// ExitCode is a status the command exits with.
+type ExitCode struct {
+	Code int
+	// Description is the comment on the line of the os.Exit call or just
+	// above it, or the comment of the constant passed to it.
+	Description string
+}
+
+// exitCodes returns the statuses passed to os.Exit as constants in files
+// except tests, sorted by the code.
+func exitCodes(fset *token.FileSet, files []*ast.File) []ExitCode {
+	var sources []*ast.File
+	for _, f := range files {
+		if !strings.HasSuffix(fset.Position(f.Package).Filename, "_test.go") {
+			sources = append(sources, f)
+		}
+	}
+
+	consts := packageConstants(sources)
+
+	var codes []ExitCode
+	index := map[int]int{}
+	for _, f := range sources {
+		comments := lineComments(fset, f)
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 || !isSelector(call.Fun, "os", "Exit") {
+				return true
+			}
+			v := consts.eval(call.Args[0], 0)
+			code, ok := constant.Int64Val(constant.ToInt(v))
+			if !ok {
+				return true
+			}
+
+			desc := comments.near(fset.Position(call.Pos()).Line)
+			if id, ok := call.Args[0].(*ast.Ident); ok && desc == "" {
+				desc = consts.descriptions[id.Name]
+			}
+			if i, ok := index[int(code)]; ok {
+				if codes[i].Description == "" {
+					codes[i].Description = desc
+				}
+				return true
+			}
+			index[int(code)] = len(codes)
+			codes = append(codes, ExitCode{Code: int(code), Description: desc})
+			return true
+		})
+	}
+
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// constants are the package-level constants of a package, evaluated on
+// demand.
+type constants struct {
+	// specs are the expressions of the constants with their iota.
+	specs        map[string]constantSpec
+	values       map[string]constant.Value
+	descriptions map[string]string
+}
+
+type constantSpec struct {
+	expr ast.Expr
+	iota int
+}
+
+// packageConstants collects the package-level constants in files.
+func packageConstants(files []*ast.File) *constants {
+	c := &constants{
+		specs:        map[string]constantSpec{},
+		values:       map[string]constant.Value{},
+		descriptions: map[string]string{},
+	}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			// the values are repeated from the last spec if omitted
+			var last []ast.Expr
+			for i, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if len(vs.Values) > 0 {
+					last = vs.Values
+				}
+				for j, name := range vs.Names {
+					if j < len(last) {
+						c.specs[name.Name] = constantSpec{expr: last[j], iota: i}
+					}
+					doc := vs.Doc
+					if doc == nil && !gen.Lparen.IsValid() {
+						doc = gen.Doc
+					}
+					if doc == nil {
+						doc = vs.Comment
+					}
+					if doc != nil {
+						c.descriptions[name.Name] = strings.Join(strings.Fields(doc.Text()), " ")
+					}
+				}
+			}
+		}
+	}
+	return c
+}
+
+// eval returns the value of the constant expression expr, or an unknown
+// value if it is not a constant of the package.
+func (c *constants) eval(expr ast.Expr, iota int) constant.Value {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return constant.MakeFromLiteral(e.Value, e.Kind, 0)
+	case *ast.ParenExpr:
+		return c.eval(e.X, iota)
+	case *ast.UnaryExpr:
+		x := c.eval(e.X, iota)
+		if x.Kind() != constant.Int || e.Op != token.ADD && e.Op != token.SUB && e.Op != token.XOR {
+			return constant.MakeUnknown()
+		}
+		return constant.UnaryOp(e.Op, x, 0)
+	case *ast.BinaryExpr:
+		x, y := c.eval(e.X, iota), c.eval(e.Y, iota)
+		if x.Kind() != constant.Int || y.Kind() != constant.Int {
+			return constant.MakeUnknown()
+		}
+		switch e.Op {
+		case token.SHL, token.SHR:
+			s, ok := constant.Uint64Val(y)
+			if !ok {
+				return constant.MakeUnknown()
+			}
+			return constant.Shift(x, e.Op, uint(s))
+		case token.QUO, token.REM:
+			if constant.Sign(y) == 0 {
+				return constant.MakeUnknown()
+			}
+			if e.Op == token.QUO {
+				// integer division
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y)
+			}
+			return constant.BinaryOp(x, e.Op, y)
+		case token.ADD, token.SUB, token.MUL, token.AND, token.OR, token.XOR, token.AND_NOT:
+			return constant.BinaryOp(x, e.Op, y)
+		}
+		return constant.MakeUnknown()
+	case *ast.Ident:
+		if e.Name == "iota" {
+			return constant.MakeInt64(int64(iota))
+		}
+		if v, ok := c.values[e.Name]; ok {
+			return v
+		}
+		spec, ok := c.specs[e.Name]
+		if !ok {
+			return constant.MakeUnknown()
+		}
+		// guards against cycles
+		c.values[e.Name] = constant.MakeUnknown()
+		v := c.eval(spec.expr, spec.iota)
+		c.values[e.Name] = v
+		return v
+	}
+	return constant.MakeUnknown()
+}
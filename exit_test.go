@@ -0,0 +1,56 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestExitCodes(t *testing.T) {
+	src := `package main
+
+import "os"
+
+const (
+	exitOK = iota
+	exitError // something went wrong
+	exitUsage
+)
+
+// exitTimeout is returned when the deadline exceeds.
+const exitTimeout = exitUsage << 2
+
+func main() {
+	if len(os.Args) < 2 {
+		// invalid arguments
+		os.Exit(exitUsage)
+	}
+	if err := run(); err != nil {
+		os.Exit(exitError)
+	}
+	os.Exit(exitTimeout)
+	os.Exit(status)
+	os.Exit(100 / 0)
+	os.Exit(3) // conflict
+	os.Exit(0)
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ExitCode{
+		{Code: 0},
+		{Code: 1, Description: "something went wrong"},
+		{Code: 2, Description: "invalid arguments"},
+		{Code: 3, Description: "conflict"},
+		{Code: 8, Description: "exitTimeout is returned when the deadline exceeds."},
+	}
+	if got := exitCodes(fset, []*ast.File{f}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+}
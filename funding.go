@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Sponsor is a link to sponsor the project.
+type Sponsor struct {
+	// Name is the name of the platform, e.g. "GitHub Sponsors".
+	Name string
+	// URL is the URL of the sponsorship page.
+	URL string
+}
+
+// fundingPlatforms are the platforms of FUNDING.yml in the order to show,
+// with the formats of their URLs by the usernames.
+var fundingPlatforms = []struct {
+	key, name, format string
+}{
+	{"github", "GitHub Sponsors", "https://github.com/sponsors/%s"},
+	{"open_collective", "Open Collective", "https://opencollective.com/%s"},
+	{"ko_fi", "Ko-fi", "https://ko-fi.com/%s"},
+	{"patreon", "Patreon", "https://www.patreon.com/%s"},
+	{"liberapay", "Liberapay", "https://liberapay.com/%s"},
+	{"buy_me_a_coffee", "Buy Me a Coffee", "https://www.buymeacoffee.com/%s"},
+	{"polar", "Polar", "https://polar.sh/%s"},
+	{"thanks_dev", "thanks.dev", "https://thanks.dev/%s"},
+	{"tidelift", "Tidelift", "https://tidelift.com/funding/github/%s"},
+	{"issuehunt", "IssueHunt", "https://issuehunt.io/r/%s"},
+	{"community_bridge", "LFX Mentorship", "https://crowdfunding.lfx.linuxfoundation.org/projects/%s"},
+	{"lfx_crowdfunding", "LFX Crowdfunding", "https://crowdfunding.lfx.linuxfoundation.org/projects/%s"},
+}
+
+// stringList is a YAML value of either a string or a list of strings.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		if s != "" {
+			*l = stringList{s}
+		}
+		return nil
+	}
+	return unmarshal((*[]string)(l))
+}
+
+// detectSponsors reads .github/FUNDING.yml of the repository containing dir
+// and returns the sponsorship links in it.
+func detectSponsors(dir string) ([]Sponsor, error) {
+	root := repoRoot(dir)
+	if root == "" {
+		return nil, nil
+	}
+
+	file := filepath.Join(".github", "FUNDING.yml")
+	b, err := ioutil.ReadFile(filepath.Join(root, file))
+	if err != nil {
+		return nil, nil
+	}
+
+	var funding map[string]stringList
+	if err := yaml.Unmarshal(b, &funding); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	var sponsors []Sponsor
+	for _, p := range fundingPlatforms {
+		for _, name := range funding[p.key] {
+			sponsors = append(sponsors, Sponsor{Name: p.name, URL: fmt.Sprintf(p.format, name)})
+		}
+	}
+	for _, s := range funding["custom"] {
+		name := s
+		if u, err := url.Parse(s); err == nil && u.Host != "" {
+			name = strings.TrimPrefix(u.Host, "www.")
+		}
+		sponsors = append(sponsors, Sponsor{Name: name, URL: s})
+	}
+	return sponsors, nil
+}
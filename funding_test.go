@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectSponsors(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, d := range []string{".git", ".github"} {
+		if err := os.Mkdir(filepath.Join(root, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if sponsors, err := detectSponsors(root); err != nil || sponsors != nil {
+		t.Errorf("detectSponsors() = %+v, %v, expected none", sponsors, err)
+	}
+
+	funding := `# These are supported funding model platforms
+github: [motemen, alice]
+patreon: # Replace with a single Patreon username
+open_collective: goreadme
+ko_fi: motemen
+custom: ["https://www.paypal.me/motemen"]
+`
+	if err := ioutil.WriteFile(filepath.Join(root, ".github", "FUNDING.yml"), []byte(funding), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sponsors, err := detectSponsors(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Sponsor{
+		{Name: "GitHub Sponsors", URL: "https://github.com/sponsors/motemen"},
+		{Name: "GitHub Sponsors", URL: "https://github.com/sponsors/alice"},
+		{Name: "Open Collective", URL: "https://opencollective.com/goreadme"},
+		{Name: "Ko-fi", URL: "https://ko-fi.com/motemen"},
+		{Name: "paypal.me", URL: "https://www.paypal.me/motemen"},
+	}
+	if !reflect.DeepEqual(sponsors, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", sponsors, expected)
+	}
+}
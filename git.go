@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// gitOutput runs git with args in dir and returns its trimmed standard output.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+		return "", withStatus(exitVCSOrNetwork, err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// verifyRef returns a usage error if ref is not a git revision of a commit
+// in the repository of dir.
+func verifyRef(dir, ref string) error {
+	if _, err := gitOutput(dir, "rev-parse", "--git-dir"); err != nil {
+		return err
+	}
+	if _, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return withStatus(exitUsage, fmt.Errorf("unknown git revision: %q", ref))
+	}
+	return nil
+}
+
+// latestTag returns the most recent tag reachable from ref.
+func latestTag(dir, ref string) (string, error) {
+	return gitOutput(dir, "describe", "--tags", "--abbrev=0", ref)
+}
+
+// currentVersion returns the latest semantic version tag of the module
+// containing dir reachable from HEAD, or "" if there is none. The tags of
+// a module in a subdirectory of the repository are prefixed by the path
+// of the directory, e.g. "tools/v1.2.3", which is trimmed.
+func currentVersion(dir string) string {
+	var prefix string
+	if gomod, root := findGoMod(dir), repoRoot(dir); gomod != "" && root != "" {
+		if rel, err := filepath.Rel(root, filepath.Dir(gomod)); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			prefix = filepath.ToSlash(rel) + "/"
+		}
+	}
+
+	tag, err := gitOutput(dir, "describe", "--tags", "--abbrev=0", "--match", prefix+"v*", "HEAD")
+	if err != nil {
+		return ""
+	}
+	tag = strings.TrimPrefix(tag, prefix)
+	if !semver.IsValid(tag) {
+		return ""
+	}
+	return tag
+}
+
+// latestRelease returns the highest semantic version tag in the repository
+// of dir, ignoring prereleases, or "" if there is none.
+func latestRelease(dir string) (string, error) {
+	out, err := gitOutput(dir, "tag", "--list", "v*")
+	if err != nil {
+		return "", err
+	}
+
+	var latest string
+	for _, tag := range strings.Split(out, "\n") {
+		if !semver.IsValid(tag) || semver.Prerelease(tag) != "" {
+			continue
+		}
+		if latest == "" || semver.Compare(tag, latest) > 0 {
+			latest = tag
+		}
+	}
+
+	return latest, nil
+}
+
+// Release is a release of the package, which is an annotated tag.
+type Release struct {
+	// Tag is the name of the tag, e.g. "v1.2.0".
+	Tag string
+	// Date is the date of the tag in the form of "2006-01-02".
+	Date string
+	// Message is the message of the tag.
+	Message string
+	// URL is the URL of the release on the hosting service, if known.
+	URL string
+}
+
+// tagReleases returns the latest n annotated tags in the repository of dir
+// as releases, newest first. Lightweight tags are ignored.
+func tagReleases(dir string, n int) ([]Release, error) {
+	// records separated by "\x1e", fields by "\x00"
+	out, err := gitOutput(dir, "for-each-ref", "--sort=-v:refname", "--sort=-creatordate", "--format=%(refname:short)%00%(objecttype)%00%(creatordate:short)%00%(contents:subject)%0a%0a%(contents:body)%1e", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []Release
+	for _, record := range strings.Split(out, "\x1e") {
+		f := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 4)
+		if len(f) < 4 || f[1] != "tag" {
+			continue
+		}
+		releases = append(releases, Release{Tag: f[0], Date: f[2], Message: strings.TrimSpace(f[3])})
+		if len(releases) == n {
+			break
+		}
+	}
+
+	return releases, nil
+}
+
+// Contributor is a person who committed to the repository.
+type Contributor struct {
+	Person
+	// Commits is the number of the commits.
+	Commits int
+	// Avatar is the URL of the avatar image, available only from the
+	// GitHub API.
+	Avatar string
+}
+
+// shortlog returns the authors of the commits in the repository of dir
+// touching paths, in descending order of the number of commits.
+func shortlog(dir string, paths ...string) ([]Contributor, error) {
+	// "  12\tName <email>"
+	out, err := gitOutput(dir, append([]string{"shortlog", "-sne", "HEAD", "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var contributors []Contributor
+	for _, line := range strings.Split(out, "\n") {
+		i := strings.Index(line, "\t")
+		if i == -1 {
+			continue
+		}
+		commits, err := strconv.Atoi(strings.TrimSpace(line[:i]))
+		if err != nil {
+			continue
+		}
+
+		c := Contributor{Commits: commits}
+		name := line[i+1:]
+		if j := strings.LastIndex(name, " <"); j != -1 && strings.HasSuffix(name, ">") {
+			c.Email = name[j+2 : len(name)-1]
+			name = name[:j]
+		}
+		c.Name = name
+		contributors = append(contributors, c)
+	}
+
+	return contributors, nil
+}
+
+// topCommitAuthor returns the author of the most commits in the repository
+// of dir.
+func topCommitAuthor(dir string) (Person, bool) {
+	contributors, err := shortlog(dir)
+	if err != nil || len(contributors) == 0 {
+		return Person{}, false
+	}
+	return contributors[0].Person, true
+}
+
+// parseDir is like parser.ParseDir, but when ref is not empty,
+// reads the Go files in dir as of the git revision ref. If ctx is not nil,
+// only the files satisfying the build constraints in ctx are parsed.
+func parseDir(fset *token.FileSet, dir, ref string, ctx *build.Context) (map[string]*ast.Package, error) {
+	if ref == "" {
+		var filter func(os.FileInfo) bool
+		if ctx != nil {
+			filter = func(fi os.FileInfo) bool {
+				return matchFile(ctx, dir, fi.Name(), nil)
+			}
+		}
+		pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
+		return pkgs, withStatus(exitParseError, err)
+	}
+
+	if err := verifyRef(dir, ref); err != nil {
+		return nil, err
+	}
+
+	out, err := gitOutput(dir, "ls-tree", "--name-only", ref, "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := map[string]*ast.Package{}
+	for _, name := range strings.Split(out, "\n") {
+		if !strings.HasSuffix(name, ".go") || strings.Contains(name, "/") {
+			continue
+		}
+
+		src, err := gitOutput(dir, "show", ref+":./"+name)
+		if err != nil {
+			return nil, err
+		}
+		if ctx != nil && !matchFile(ctx, dir, name, []byte(src)) {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+		if err != nil {
+			return nil, withStatus(exitParseError, err)
+		}
+
+		pkgName := f.Name.Name
+		pkg, ok := pkgs[pkgName]
+		if !ok {
+			pkg = &ast.Package{Name: pkgName, Files: map[string]*ast.File{}}
+			pkgs[pkgName] = pkg
+		}
+		pkg.Files[name] = f
+	}
+
+	return pkgs, nil
+}
+
+// buildContext returns the context to select the files for the platform
+// goos/goarch, the current one if empty, with tags, or nil if tags is nil
+// and no platform is given so that all the files are parsed. The cgo tag is
+// satisfied only if it is in tags, rather than by CGO_ENABLED.
+func buildContext(tags []string, goos, goarch string) *build.Context {
+	if tags == nil && goos == "" && goarch == "" {
+		return nil
+	}
+	ctx := build.Default
+	ctx.BuildTags = tags
+	ctx.CgoEnabled = false
+	if goos != "" {
+		ctx.GOOS = goos
+	}
+	if goarch != "" {
+		ctx.GOARCH = goarch
+	}
+	return &ctx
+}
+
+// matchFile reports whether the Go file name in dir satisfies the build
+// constraints in ctx, including the ones by its name such as _windows.go.
+// The content is read from src if not nil.
+func matchFile(ctx *build.Context, dir, name string, src []byte) bool {
+	c := *ctx
+	if src != nil {
+		c.OpenFile = func(string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(src)), nil
+		}
+	}
+	ok, err := c.MatchFile(dir, name)
+	return err == nil && ok
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestTopCommitAuthor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := gitOutput(dir, "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+
+	if a, ok := topCommitAuthor(dir); ok {
+		t.Errorf("topCommitAuthor should fail without commits: %+v", a)
+	}
+
+	commit := func(name, email string) {
+		_, err := gitOutput(dir, "-c", "user.name="+name, "-c", "user.email="+email, "commit", "-q", "--allow-empty", "-m", "commit")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit("alice", "alice@example.com")
+	commit("Bob Smith", "bob@example.com")
+	commit("Bob Smith", "bob@example.com")
+
+	a, ok := topCommitAuthor(dir)
+	expected := Person{Name: "Bob Smith", Email: "bob@example.com"}
+	if !ok || a != expected {
+		t.Errorf("topCommitAuthor() = %+v, %v, expected %+v", a, ok, expected)
+	}
+}
+
+func TestShortlog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := gitOutput(dir, "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(name, file string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := gitOutput(dir, "add", "."); err != nil {
+			t.Fatal(err)
+		}
+		_, err := gitOutput(dir, "-c", "user.name="+name, "-c", "user.email="+name+"@example.com", "commit", "-q", "-m", "commit")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit("alice", "a.txt")
+	commit("bob", "sub/b.txt")
+	commit("alice", "sub/b.txt")
+	commit("bob", "sub/c.txt")
+	commit("bob", "a.txt")
+
+	for _, test := range []struct {
+		paths    []string
+		expected []Contributor
+	}{
+		{nil, []Contributor{
+			{Person: Person{Name: "bob", Email: "bob@example.com"}, Commits: 3},
+			{Person: Person{Name: "alice", Email: "alice@example.com"}, Commits: 2},
+		}},
+		{[]string{"sub"}, []Contributor{
+			{Person: Person{Name: "bob", Email: "bob@example.com"}, Commits: 2},
+			{Person: Person{Name: "alice", Email: "alice@example.com"}, Commits: 1},
+		}},
+	} {
+		got, err := shortlog(dir, test.paths...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("shortlog(%q) = %+v, expected %+v", test.paths, got, test.expected)
+		}
+	}
+}
+
+func TestTagReleases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) {
+		if _, err := gitOutput(dir, append([]string{"-c", "user.name=alice", "-c", "user.email=alice@example.com"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "initial")
+	git("tag", "-a", "v0.1.0", "-m", "First release")
+	git("tag", "v0.1.1")
+	git("commit", "-q", "--allow-empty", "-m", "second")
+	git("tag", "-a", "v0.2.0", "-m", "Add Bar", "-m", "- Bar\n- Baz")
+
+	releases, err := tagReleases(dir, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][2]string
+	for _, rel := range releases {
+		got = append(got, [2]string{rel.Tag, rel.Message})
+		if rel.Date == "" {
+			t.Errorf("%s has no date", rel.Tag)
+		}
+	}
+	expected := [][2]string{
+		{"v0.2.0", "Add Bar\n\n- Bar\n- Baz"},
+		{"v0.1.0", "First release"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("tagReleases() = %q, expected %q", got, expected)
+	}
+
+	if releases, err := tagReleases(dir, 1); err != nil || len(releases) != 1 {
+		t.Errorf("tagReleases(1) = %+v, %v", releases, err)
+	}
+}
+
+func TestCurrentVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) {
+		if _, err := gitOutput(dir, append([]string{"-c", "user.name=alice", "-c", "user.email=alice@example.com"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "initial")
+
+	if v := currentVersion(dir); v != "" {
+		t.Errorf("currentVersion() = %q, expected none", v)
+	}
+
+	git("tag", "v1.2.3")
+	git("commit", "-q", "--allow-empty", "-m", "second")
+	if v, expected := currentVersion(dir), "v1.2.3"; v != expected {
+		t.Errorf("currentVersion() = %q, expected %q", v, expected)
+	}
+
+	git("tag", "nightly")
+	if v, expected := currentVersion(dir), "v1.2.3"; v != expected {
+		t.Errorf("currentVersion() = %q, expected %q", v, expected)
+	}
+
+	// a module in a subdirectory
+	sub := filepath.Join(dir, "tools")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(sub, "go.mod"), []byte("module example.com/foo/tools\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if v := currentVersion(sub); v != "" {
+		t.Errorf("currentVersion(tools) = %q, expected none", v)
+	}
+	git("tag", "tools/v0.3.0")
+	if v, expected := currentVersion(sub), "v0.3.0"; v != expected {
+		t.Errorf("currentVersion(tools) = %q, expected %q", v, expected)
+	}
+}
+
+func TestParseDir_tags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"foo.go":                  "package foo\n",
+		"integration.go":          "//go:build integration\n\npackage foo\n",
+		"example_int_test.go":     "//go:build integration\n\npackage foo_test\n",
+		"gen.go":                  "//go:build ignore\n\npackage main\n",
+		"foo_windows.go":          "package foo\n",
+		"foo_amd64.go":            "package foo\n",
+		"example_windows_test.go": "package foo_test\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git := func(args ...string) {
+		if _, err := gitOutput(dir, append([]string{"-c", "user.name=alice", "-c", "user.email=alice@example.com"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	files := func(pkgs map[string]*ast.Package) []string {
+		var names []string
+		for _, pkg := range pkgs {
+			for name := range pkg.Files {
+				names = append(names, filepath.Base(name))
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	tests := []struct {
+		tags         []string
+		goos, goarch string
+		expected     []string
+	}{
+		{nil, "", "", []string{"example_int_test.go", "example_windows_test.go", "foo.go", "foo_amd64.go", "foo_windows.go", "gen.go", "integration.go"}},
+		{[]string{}, "linux", "arm64", []string{"foo.go"}},
+		{[]string{"integration"}, "linux", "arm64", []string{"example_int_test.go", "foo.go", "integration.go"}},
+		{nil, "windows", "amd64", []string{"example_windows_test.go", "foo.go", "foo_amd64.go", "foo_windows.go"}},
+		{nil, "linux", "amd64", []string{"foo.go", "foo_amd64.go"}},
+	}
+	for _, test := range tests {
+		for _, ref := range []string{"", "HEAD"} {
+			ctx := buildContext(test.tags, test.goos, test.goarch)
+			pkgs, err := parseDir(token.NewFileSet(), dir, ref, ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := files(pkgs); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("parseDir(%q, %q, %s/%s) = %q, expected %q", ref, test.tags, test.goos, test.goarch, got, test.expected)
+			}
+		}
+	}
+}
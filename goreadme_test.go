@@ -1,6 +1,10 @@
 package main
 
 import (
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
 	"testing"
 )
 
@@ -30,23 +34,375 @@ a Markdown content suitable as a README boilerplate.
 ` + "For the default template, run `go doc github.com/motemen/goreadme.DefaultTemplate`.\n",
 		},
 		{
+			from: "Package kanji は http.Clientを拡張し、Ünïcode.Fönction（例）を提供します。",
+			to:   "Package kanji は `http.Client`を拡張し、`Ünïcode.Fönction`（例）を提供します。\n",
+		},
+		{
+			from: "Call Écrire.Résumé2 with http.Client　please.",
+			to:   "Call `Écrire.Résumé2` with `http.Client`　please.\n",
+		},
+		{
+			// Since Go 1.19, indentation common to all lines is not significant
 			from: `
     foo bar
   x   1   2
   y   3   4
 `,
-			to: `      foo bar
-    x   1   2
-    y   3   4
+			to: `    foo bar
 
+x   1   2
+y   3   4
 `,
 		},
 	}
 
 	for _, c := range cases {
-		rendered := squeezeEmptyLines(renderMarkdown(c.from, []string{}))
+		rendered := squeezeEmptyLines(renderMarkdown(c.from, nil, markdownOptions{HeadingLevel: 2}))
+		if rendered != c.to {
+			t.Errorf("renderMarkdown mismatch:\nGot ---\n%q\nExpected ---\n%q\n", rendered, c.to)
+		}
+	}
+}
+
+func TestRenderMarkdown_urls(t *testing.T) {
+	cases := []struct {
+		from string
+		to   string
+	}{
+		{
+			from: "See https://example.com/a_b.",
+			to:   "See [https://example.com/a_b](https://example.com/a_b).\n",
+		},
+		{
+			from: "Docs (https://pkg.go.dev/io#Reader), or http://example.com/x?y=1!",
+			to:   "Docs ([https://pkg.go.dev/io#Reader](https://pkg.go.dev/io#Reader)), or [http://example.com/x?y=1](http://example.com/x?y=1)!\n",
+		},
+		{
+			from: "Wiki: https://en.wikipedia.org/wiki/Go_(programming_language)",
+			to:   "Wiki: [https://en.wikipedia.org/wiki/Go\\_(programming_language)](https://en.wikipedia.org/wiki/Go_(programming_language))\n",
+		},
+	}
+
+	for _, c := range cases {
+		rendered := squeezeEmptyLines(renderMarkdown(c.from, nil, markdownOptions{HeadingLevel: 2}))
 		if rendered != c.to {
 			t.Errorf("renderMarkdown mismatch:\nGot ---\n%q\nExpected ---\n%q\n", rendered, c.to)
 		}
 	}
 }
+
+func TestRenderMarkdown_lists(t *testing.T) {
+	cases := []struct {
+		from string
+		to   string
+	}{
+		{
+			from: `Tight list:
+  - one
+  - two
+`,
+			to: `Tight list:
+
+- one
+- two
+`,
+		},
+		{
+			from: `Numbered list:
+ 1. first
+ 2. second
+    continued
+`,
+			to: `Numbered list:
+
+1. first
+2. second
+   continued
+`,
+		},
+		{
+			from: `Nested list:
+  - parent
+      - child
+      - child 2
+  - sibling
+`,
+			to: `Nested list:
+
+- parent
+  - child
+  - child 2
+- sibling
+`,
+		},
+		{
+			from: `Numbering is preserved:
+  3) three
+  4) four
+`,
+			to: `Numbering is preserved:
+
+3. three
+4. four
+`,
+		},
+		{
+			from: `Nested in numbered list:
+ 9. nine
+     - sub
+ 10. ten
+     1. deep
+`,
+			to: `Nested in numbered list:
+
+9. nine
+   - sub
+10. ten
+    1. deep
+`,
+		},
+		{
+			from: `Multi-paragraph items:
+
+  - one
+
+    second paragraph of one
+
+  - two
+`,
+			to: `Multi-paragraph items:
+
+- one
+
+  second paragraph of one
+
+- two
+`,
+		},
+	}
+
+	for _, c := range cases {
+		rendered := squeezeEmptyLines(renderMarkdown(c.from, nil, markdownOptions{HeadingLevel: 2}))
+		if rendered != c.to {
+			t.Errorf("renderMarkdown mismatch:\nGot ---\n%s\nExpected ---\n%s\n", rendered, c.to)
+		}
+	}
+}
+
+func TestRenderMarkdown_docLinks(t *testing.T) {
+	src := `// Package foo is foo.
+package foo
+
+import "net/http"
+
+type Client struct{ c *http.Client }
+
+func (c *Client) Do() {}
+`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{f}, "example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		from string
+		to   string
+	}{
+		{
+			from: "Use [Client] to call [Client.Do].",
+			to:   "Use [`Client`](https://pkg.go.dev/example.com/foo#Client) to call [`Client.Do`](https://pkg.go.dev/example.com/foo#Client.Do).\n",
+		},
+		{
+			from: "Wraps [http.Client] and [*net/http.Request].",
+			to:   "Wraps [`http.Client`](https://pkg.go.dev/net/http#Client) and [`*net/http.Request`](https://pkg.go.dev/net/http#Request).\n",
+		},
+		{
+			from: "Not a link: [Unknown].",
+			to:   "Not a link: [Unknown].\n",
+		},
+		{
+			from: "Run `go test -run Foo_bar` for *_test.go.\n",
+			to:   "Run `go test -run Foo_bar` for \\*\\_test.go.\n",
+		},
+		{
+			from: "Calls (*Client).Do with *opts_ and [Client].\n",
+			to:   "Calls `(*Client).Do` with \\*opts\\_ and [`Client`](https://pkg.go.dev/example.com/foo#Client).\n",
+		},
+	}
+
+	for _, c := range cases {
+		rendered := squeezeEmptyLines(renderMarkdown(c.from, pkg, markdownOptions{HeadingLevel: 2}))
+		if rendered != c.to {
+			t.Errorf("renderMarkdown mismatch:\nGot ---\n%q\nExpected ---\n%q\n", rendered, c.to)
+		}
+	}
+}
+
+func TestRenderMarkdown_linkIdents(t *testing.T) {
+	src := `// Package foo is foo.
+package foo
+
+import "net/http"
+
+type Client struct{ c *http.Client }
+
+func (c *Client) Do() {}
+
+func helper() {}
+`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{f}, "example.com/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		from string
+		to   string
+	}{
+		{
+			from: "Client wraps http.Client.",
+			to:   "[`Client`](https://pkg.go.dev/example.com/foo#Client) wraps [`http.Client`](https://pkg.go.dev/net/http#Client).\n",
+		},
+		{
+			from: "Call (*Client).Do or Client.Do().",
+			to:   "Call [`(*Client).Do`](https://pkg.go.dev/example.com/foo#Client.Do) or [`Client.Do()`](https://pkg.go.dev/example.com/foo#Client.Do).\n",
+		},
+		{
+			from: "See golang.org/x/mod/modfile.File and io.Reader.",
+			to:   "See [`golang.org/x/mod/modfile.File`](https://pkg.go.dev/golang.org/x/mod/modfile#File) and `io.Reader`.\n",
+		},
+		{
+			from: "Unknown Client.Undefined stays as code.",
+			to:   "Unknown `Client.Undefined` stays as code.\n",
+		},
+	}
+
+	for _, c := range cases {
+		rendered := squeezeEmptyLines(renderMarkdown(c.from, pkg, markdownOptions{HeadingLevel: 2, LinkIdents: true}))
+		if rendered != c.to {
+			t.Errorf("renderMarkdown mismatch:\nGot ---\n%q\nExpected ---\n%q\n", rendered, c.to)
+		}
+	}
+}
+
+func TestRenderMarkdown_headings(t *testing.T) {
+	cases := []struct {
+		from  string
+		level int
+		to    string
+	}{
+		{
+			from:  "Intro.\n\n# Usage\n\nText.\n",
+			level: 2,
+			to:    "Intro.\n\n## Usage\n\nText.\n",
+		},
+		{
+			from:  "Intro.\n\n# Usage\n\nText.\n",
+			level: 4,
+			to:    "Intro.\n\n#### Usage\n\nText.\n",
+		},
+		{
+			from:  "Intro.\n\nImplicit Heading\n\nText.\n",
+			level: 3,
+			to:    "Intro.\n\n### Implicit Heading\n\nText.\n",
+		},
+		{
+			from:  "Intro.\n\n# Deep\n\nText.\n",
+			level: 8,
+			to:    "Intro.\n\n###### Deep\n\nText.\n",
+		},
+	}
+
+	for _, c := range cases {
+		rendered := squeezeEmptyLines(renderMarkdown(c.from, nil, markdownOptions{HeadingLevel: c.level}))
+		if rendered != c.to {
+			t.Errorf("renderMarkdown mismatch:\nGot ---\n%q\nExpected ---\n%q\n", rendered, c.to)
+		}
+	}
+}
+
+func TestRenderMarkdown_fence(t *testing.T) {
+	cases := []struct {
+		from string
+		opts markdownOptions
+		to   string
+	}{
+		{
+			from: "Example:\n\n\tfoo()\n\tbar()\n",
+			opts: markdownOptions{Fence: true},
+			to:   "Example:\n\n```\nfoo()\nbar()\n```\n",
+		},
+		{
+			from: "Example:\n\n\tfoo()\n",
+			opts: markdownOptions{Fence: true, FenceLang: "go"},
+			to:   "Example:\n\n```go\nfoo()\n```\n",
+		},
+		{
+			from: "Example:\n\n\tx := ```\n",
+			opts: markdownOptions{Fence: true},
+			to:   "Example:\n\n````\nx := ```\n````\n",
+		},
+	}
+
+	for _, c := range cases {
+		rendered := squeezeEmptyLines(renderMarkdown(c.from, nil, c.opts))
+		if rendered != c.to {
+			t.Errorf("renderMarkdown mismatch:\nGot ---\n%q\nExpected ---\n%q\n", rendered, c.to)
+		}
+	}
+}
+
+func TestDetectCodeLang(t *testing.T) {
+	cases := []struct {
+		code string
+		lang string
+	}{
+		{"package main\n\nfunc main() {}\n", "go"},
+		{"func Foo() error {\n\treturn nil\n}\n", "go"},
+		{"c := foo.New()\nc.Do(ctx)\n", "go"},
+		{"go get -u github.com/motemen/goreadme\n", "sh"},
+		{"$ goreadme > README.md\n", "sh"},
+		{`{"name": "foo", "tags": [1, 2]}` + "\n", "json"},
+		{"Hello, world\n", ""},
+		{"result\n", ""},
+	}
+
+	for _, c := range cases {
+		if lang := detectCodeLang(c.code); lang != c.lang {
+			t.Errorf("detectCodeLang(%q) = %q, expected %q", c.code, lang, c.lang)
+		}
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	cases := []struct {
+		from string
+		to   string
+	}{
+		{"snake_case_name", "snake_case_name"},
+		{"_private and __dunder__", `\_private and \_\_dunder\_\_`},
+		{"a * b and *emphasis*", `a * b and \*emphasis\*`},
+		{"a ` b | c", "a \\` b \\| c"},
+		{"1 < 2, <b>bold</b>", `1 < 2, \<b>bold\</b>`},
+		{"> quote\nnot > quote", "\\> quote\nnot > quote"},
+		{`C:\path and \*`, `C:\path and \\\*`},
+	}
+
+	for _, c := range cases {
+		if got := escapeMarkdown(c.from); got != c.to {
+			t.Errorf("escapeMarkdown mismatch:\nGot ---\n%s\nExpected ---\n%s\n", got, c.to)
+		}
+	}
+}
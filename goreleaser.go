@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// goreleaserFiles are the names of the GoReleaser configuration files.
+var goreleaserFiles = []string{".goreleaser.yml", ".goreleaser.yaml"}
+
+// goreleaserConfig is the part of the GoReleaser configuration to
+// describe the released binaries.
+type goreleaserConfig struct {
+	ProjectName string `yaml:"project_name"`
+	Builds      []struct {
+		Skip   bool     `yaml:"skip"`
+		Goos   []string `yaml:"goos"`
+		Goarch []string `yaml:"goarch"`
+		Goarm  []string `yaml:"goarm"`
+		Ignore []struct {
+			Goos   string `yaml:"goos"`
+			Goarch string `yaml:"goarch"`
+		} `yaml:"ignore"`
+	} `yaml:"builds"`
+	Archives []struct {
+		Format          string     `yaml:"format"`
+		Formats         stringList `yaml:"formats"`
+		NameTemplate    string     `yaml:"name_template"`
+		FormatOverrides []struct {
+			Goos    string     `yaml:"goos"`
+			Format  string     `yaml:"format"`
+			Formats stringList `yaml:"formats"`
+		} `yaml:"format_overrides"`
+	} `yaml:"archives"`
+	Dockers []struct {
+		ImageTemplates []string `yaml:"image_templates"`
+	} `yaml:"dockers"`
+	Brews         []goreleaserBrew `yaml:"brews"`
+	HomebrewCasks []goreleaserBrew `yaml:"homebrew_casks"`
+}
+
+// goreleaserBrew is a Homebrew formula or cask published by GoReleaser.
+type goreleaserBrew struct {
+	Name       string `yaml:"name"`
+	Repository struct {
+		Owner string `yaml:"owner"`
+		Name  string `yaml:"name"`
+	} `yaml:"repository"`
+	// Tap is the former name of Repository.
+	Tap struct {
+		Owner string `yaml:"owner"`
+		Name  string `yaml:"name"`
+	} `yaml:"tap"`
+}
+
+// loadGoReleaserConfig reads the GoReleaser configuration at the root of
+// the repository containing dir. It returns nil if there is none.
+func loadGoReleaserConfig(dir string) *goreleaserConfig {
+	root := repoRoot(dir)
+	if root == "" {
+		return nil
+	}
+
+	for _, name := range goreleaserFiles {
+		b, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			continue
+		}
+		var conf goreleaserConfig
+		if err := yaml.Unmarshal(b, &conf); err != nil {
+			return nil
+		}
+		return &conf
+	}
+
+	return nil
+}
+
+// goreleaserPlatform is a platform GoReleaser builds a binary for.
+type goreleaserPlatform struct {
+	OS, Arch, Arm string
+}
+
+// platforms returns the valid platforms of the builds in the order of
+// the configuration, with the defaults of GoReleaser.
+func (c goreleaserConfig) platforms() []goreleaserPlatform {
+	var platforms []goreleaserPlatform
+	seen := map[goreleaserPlatform]bool{}
+	for _, build := range c.Builds {
+		if build.Skip {
+			continue
+		}
+		goos, goarch, goarm := build.Goos, build.Goarch, build.Goarm
+		if len(goos) == 0 {
+			goos = []string{"darwin", "linux", "windows"}
+		}
+		if len(goarch) == 0 {
+			goarch = []string{"386", "amd64", "arm64"}
+		}
+		if len(goarm) == 0 {
+			goarm = []string{"6"}
+		}
+
+		ignored := map[string]bool{}
+		for _, ig := range build.Ignore {
+			ignored[ig.Goos+"/"+ig.Goarch] = true
+		}
+
+		for _, os := range goos {
+			for _, arch := range goarch {
+				if !ports[os+"/"+arch] || ignored[os+"/"+arch] {
+					continue
+				}
+				arms := []string{""}
+				if arch == "arm" {
+					arms = goarm
+				}
+				for _, arm := range arms {
+					p := goreleaserPlatform{OS: os, Arch: arch, Arm: arm}
+					if !seen[p] {
+						seen[p] = true
+						platforms = append(platforms, p)
+					}
+				}
+			}
+		}
+	}
+	return platforms
+}
+
+// homebrew returns the name of the Homebrew formula published by GoReleaser
+// in the form of "owner/tap/name", and whether it is a cask. project is
+// the default name of the formula. It returns "" if there is none.
+func (c goreleaserConfig) homebrew(project string) (name string, cask bool) {
+	if c.ProjectName != "" {
+		project = c.ProjectName
+	}
+
+	for i, brews := range [][]goreleaserBrew{c.Brews, c.HomebrewCasks} {
+		for _, b := range brews {
+			owner, repo := b.Repository.Owner, b.Repository.Name
+			if owner == "" {
+				owner, repo = b.Tap.Owner, b.Tap.Name
+			}
+			if owner == "" || repo == "" || strings.Contains(owner+repo, "{{") {
+				continue
+			}
+			name := b.Name
+			if name == "" {
+				name = project
+			}
+			// "brew install owner/tap/name" taps github.com/owner/homebrew-tap
+			return owner + "/" + strings.TrimPrefix(repo, "homebrew-") + "/" + name, i == 1
+		}
+	}
+	return "", false
+}
+
+// dockerImage returns the first Docker image published by GoReleaser
+// without the tag, or "" if there is none. Images whose names depend on
+// the environment are ignored.
+func (c goreleaserConfig) dockerImage() string {
+	for _, d := range c.Dockers {
+		for _, image := range d.ImageTemplates {
+			if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+				image = image[:i]
+			}
+			if image != "" && !strings.Contains(image, "{{") {
+				return image
+			}
+		}
+	}
+	return ""
+}
+
+// defaultArchiveNameTemplate is the default name_template of archives,
+// omitting the parts for MIPS and amd64 microarchitectures.
+const defaultArchiveNameTemplate = `{{ .ProjectName }}_{{ .Version }}_{{ .Os }}_{{ .Arch }}{{ with .Arm }}v{{ . }}{{ end }}`
+
+// goreleaserTemplateFuncs are the template functions of GoReleaser commonly
+// used in name_template.
+var goreleaserTemplateFuncs = template.FuncMap{
+	"tolower":    strings.ToLower,
+	"toupper":    strings.ToUpper,
+	"title":      strings.Title,
+	"trim":       strings.TrimSpace,
+	"trimprefix": strings.TrimPrefix,
+	"trimsuffix": strings.TrimSuffix,
+	"replace":    func(s, old, new string) string { return strings.Replace(s, old, new, -1) },
+}
+
+// Download is a prebuilt binary archive of a release.
+type Download struct {
+	// OS and Arch are the platform of the binary, e.g. "linux" and "amd64".
+	OS, Arch string
+	// Archive is the file name of the archive.
+	Archive string
+	// URL is the URL to download the archive, if known.
+	URL string
+}
+
+// downloads returns the archives of the binaries released as the version
+// tag by GoReleaser. project is the default project name, and assetURL
+// returns the URL of the asset of the release, or "" if not known.
+// It returns nil if the archive names could not be determined.
+func (c goreleaserConfig) downloads(project, tag string, assetURL func(tag, name string) string) []Download {
+	if tag == "" {
+		return nil
+	}
+	if c.ProjectName != "" {
+		project = c.ProjectName
+	}
+
+	nameTemplate, format := defaultArchiveNameTemplate, "tar.gz"
+	overrides := map[string]string{}
+	if len(c.Archives) > 0 {
+		a := c.Archives[0]
+		if a.NameTemplate != "" {
+			nameTemplate = a.NameTemplate
+		}
+		if len(a.Formats) > 0 {
+			format = a.Formats[0]
+		} else if a.Format != "" {
+			format = a.Format
+		}
+		for _, o := range a.FormatOverrides {
+			if len(o.Formats) > 0 {
+				overrides[o.Goos] = o.Formats[0]
+			} else if o.Format != "" {
+				overrides[o.Goos] = o.Format
+			}
+		}
+	}
+
+	tmpl, err := template.New("name_template").Funcs(goreleaserTemplateFuncs).Option("missingkey=error").Parse(nameTemplate)
+	if err != nil {
+		return nil
+	}
+
+	var downloads []Download
+	for _, p := range c.platforms() {
+		var buf bytes.Buffer
+		err := tmpl.Execute(&buf, map[string]string{
+			"ProjectName": project,
+			"Binary":      project,
+			"Version":     strings.TrimPrefix(tag, "v"),
+			"Tag":         tag,
+			"Os":          p.OS,
+			"Arch":        p.Arch,
+			"Arm":         p.Arm,
+			"Amd64":       "v1",
+			"Mips":        "",
+		})
+		if err != nil {
+			return nil
+		}
+
+		f := format
+		if o, ok := overrides[p.OS]; ok {
+			f = o
+		}
+		name := buf.String()
+		switch f {
+		case "none":
+			continue
+		case "binary":
+			if p.OS == "windows" {
+				name += ".exe"
+			}
+		default:
+			name += "." + f
+		}
+
+		d := Download{OS: p.OS, Arch: p.Arch, Archive: name}
+		if p.Arm != "" {
+			d.Arch = p.Arch + "v" + p.Arm
+		}
+		if assetURL != nil {
+			d.URL = assetURL(tag, name)
+		}
+		downloads = append(downloads, d)
+	}
+	return downloads
+}
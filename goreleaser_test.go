@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestGoReleaserConfig_downloads(t *testing.T) {
+	tests := []struct {
+		config   string
+		expected []Download
+	}{
+		{
+			config: `
+builds:
+  - goos: [linux, windows]
+    goarch: [amd64, arm]
+    goarm: ["7"]
+`,
+			expected: []Download{
+				{OS: "linux", Arch: "amd64", Archive: "tool_1.2.0_linux_amd64.tar.gz"},
+				{OS: "linux", Arch: "armv7", Archive: "tool_1.2.0_linux_armv7.tar.gz"},
+				{OS: "windows", Arch: "amd64", Archive: "tool_1.2.0_windows_amd64.tar.gz"},
+				{OS: "windows", Arch: "armv7", Archive: "tool_1.2.0_windows_armv7.tar.gz"},
+			},
+		},
+		{
+			config: `
+project_name: mytool
+builds:
+  - goos: [darwin, windows]
+    goarch: [arm64]
+archives:
+  - format: tar.xz
+    name_template: '{{ .ProjectName }}-{{ .Tag }}-{{ title .Os }}-{{ .Arch }}'
+    format_overrides:
+      - goos: windows
+        format: zip
+`,
+			expected: []Download{
+				{OS: "darwin", Arch: "arm64", Archive: "mytool-v1.2.0-Darwin-arm64.tar.xz"},
+				{OS: "windows", Arch: "arm64", Archive: "mytool-v1.2.0-Windows-arm64.zip"},
+			},
+		},
+		{
+			config: `
+archives:
+  - name_template: '{{ .Unknown }}'
+`,
+			expected: nil,
+		},
+	}
+
+	for _, test := range tests {
+		var conf goreleaserConfig
+		if err := yaml.Unmarshal([]byte(test.config), &conf); err != nil {
+			t.Fatal(err)
+		}
+		got := conf.downloads("tool", "v1.2.0", nil)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("downloads() with %s\nGot ---\n%+v\nExpected ---\n%+v", test.config, got, test.expected)
+		}
+	}
+}
+
+func TestGoReleaserConfig_homebrew(t *testing.T) {
+	tests := []struct {
+		config   string
+		expected string
+		cask     bool
+	}{
+		{"builds: [{}]\n", "", false},
+		{"brews:\n  - repository:\n      owner: motemen\n      name: homebrew-tap\n", "motemen/tap/tool", false},
+		{"brews:\n  - name: goreadme\n    tap:\n      owner: motemen\n      name: homebrew-tools\n", "motemen/tools/goreadme", false},
+		{"project_name: app\nhomebrew_casks:\n  - repository:\n      owner: motemen\n      name: homebrew-tap\n", "motemen/tap/app", true},
+		{"brews:\n  - repository:\n      owner: '{{ .Env.OWNER }}'\n      name: homebrew-tap\n", "", false},
+	}
+
+	for _, test := range tests {
+		var conf goreleaserConfig
+		if err := yaml.Unmarshal([]byte(test.config), &conf); err != nil {
+			t.Fatal(err)
+		}
+		if got, cask := conf.homebrew("tool"); got != test.expected || cask != test.cask {
+			t.Errorf("homebrew() with %q = %q, %v, expected %q, %v", test.config, got, cask, test.expected, test.cask)
+		}
+	}
+}
+
+func TestGoReleaserConfig_dockerImage(t *testing.T) {
+	tests := []struct {
+		config   string
+		expected string
+	}{
+		{"builds: [{}]\n", ""},
+		{"dockers:\n  - image_templates:\n      - 'motemen/tool:{{ .Version }}'\n      - 'motemen/tool:latest'\n", "motemen/tool"},
+		{"dockers:\n  - image_templates:\n      - '{{ .Env.REGISTRY }}/tool:latest'\n  - image_templates:\n      - 'ghcr.io/motemen/tool:latest'\n", "ghcr.io/motemen/tool"},
+	}
+
+	for _, test := range tests {
+		var conf goreleaserConfig
+		if err := yaml.Unmarshal([]byte(test.config), &conf); err != nil {
+			t.Fatal(err)
+		}
+		if got := conf.dockerImage(); got != test.expected {
+			t.Errorf("dockerImage() with %q = %q, expected %q", test.config, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"strings"
+)
+
+// Host is a source code hosting service, or a forge, such as GitHub.
+type Host interface {
+	// RepoURL returns the URL of the repository.
+	RepoURL(r Repository) string
+	// BadgeURLs returns the status badges of the CI service built in the host,
+	// such as GitHub Actions, for the package t.
+	BadgeURLs(t BadgeTarget) []Badge
+	// AuthorFromPath returns the user or the group owning the repository
+	// as the author.
+	AuthorFromPath(r Repository) Person
+	// SourceLink returns the URL of the file at path in the repository
+	// as of ref, e.g. a branch.
+	SourceLink(r Repository, ref, path string) string
+	// ReleaseLink returns the URL of the release of tag.
+	ReleaseLink(r Repository, tag string) string
+	// ReleasesLink returns the URL of the list of the releases.
+	ReleasesLink(r Repository) string
+	// AssetLink returns the URL to download the file name attached to
+	// the release of tag, or "" if not supported.
+	AssetLink(r Repository, tag, name string) string
+	// DefaultBranch returns the default branch of new repositories.
+	DefaultBranch() string
+}
+
+// hosts are the hosting services by their kinds.
+var hosts = map[string]Host{
+	"github":    githubHost{},
+	"gitlab":    gitlabHost{},
+	"bitbucket": bitbucketHost{},
+	"gitea":     giteaHost{},
+	"sourcehut": sourcehutHost{},
+}
+
+// baseHost implements the methods of Host common to most hosts.
+type baseHost struct{}
+
+func (baseHost) RepoURL(r Repository) string {
+	return "https://" + r.Host + "/" + r.Path
+}
+
+func (baseHost) AuthorFromPath(r Repository) Person {
+	owner := strings.SplitN(r.Path, "/", 2)[0]
+	return Person{Name: owner, URL: "https://" + r.Host + "/" + owner}
+}
+
+func (baseHost) DefaultBranch() string { return "main" }
+
+func (baseHost) AssetLink(r Repository, tag, name string) string { return "" }
+
+type githubHost struct{ baseHost }
+
+func (githubHost) BadgeURLs(t BadgeTarget) []Badge { return githubActionsBadges(t) }
+
+func (h githubHost) SourceLink(r Repository, ref, path string) string {
+	return h.RepoURL(r) + "/blob/" + ref + "/" + path
+}
+
+func (h githubHost) ReleaseLink(r Repository, tag string) string {
+	return h.RepoURL(r) + "/releases/tag/" + tag
+}
+
+func (h githubHost) ReleasesLink(r Repository) string {
+	return h.RepoURL(r) + "/releases"
+}
+
+func (h githubHost) AssetLink(r Repository, tag, name string) string {
+	return h.RepoURL(r) + "/releases/download/" + tag + "/" + name
+}
+
+type gitlabHost struct{ baseHost }
+
+func (gitlabHost) BadgeURLs(t BadgeTarget) []Badge { return gitlabCIBadges(t) }
+
+func (h gitlabHost) SourceLink(r Repository, ref, path string) string {
+	return h.RepoURL(r) + "/-/blob/" + ref + "/" + path
+}
+
+func (h gitlabHost) ReleaseLink(r Repository, tag string) string {
+	return h.RepoURL(r) + "/-/releases/" + tag
+}
+
+func (h gitlabHost) ReleasesLink(r Repository) string {
+	return h.RepoURL(r) + "/-/releases"
+}
+
+type bitbucketHost struct{ baseHost }
+
+func (bitbucketHost) BadgeURLs(t BadgeTarget) []Badge { return bitbucketPipelinesBadges(t) }
+
+func (h bitbucketHost) SourceLink(r Repository, ref, path string) string {
+	return h.RepoURL(r) + "/src/" + ref + "/" + path
+}
+
+// ReleaseLink returns the link to the source of tag, as Bitbucket has
+// no releases.
+func (h bitbucketHost) ReleaseLink(r Repository, tag string) string {
+	return h.RepoURL(r) + "/src/" + tag + "/"
+}
+
+// ReleasesLink returns the link to the list of the tags.
+func (h bitbucketHost) ReleasesLink(r Repository) string {
+	return h.RepoURL(r) + "/downloads/?tab=tags"
+}
+
+type giteaHost struct{ baseHost }
+
+func (giteaHost) BadgeURLs(t BadgeTarget) []Badge { return giteaActionsBadges(t) }
+
+func (h giteaHost) SourceLink(r Repository, ref, path string) string {
+	return h.RepoURL(r) + "/src/branch/" + ref + "/" + path
+}
+
+func (h giteaHost) ReleaseLink(r Repository, tag string) string {
+	return h.RepoURL(r) + "/releases/tag/" + tag
+}
+
+func (h giteaHost) ReleasesLink(r Repository) string {
+	return h.RepoURL(r) + "/releases"
+}
+
+func (h giteaHost) AssetLink(r Repository, tag, name string) string {
+	return h.RepoURL(r) + "/releases/download/" + tag + "/" + name
+}
+
+type sourcehutHost struct{ baseHost }
+
+func (sourcehutHost) BadgeURLs(t BadgeTarget) []Badge { return sourcehutBuildsBadges(t) }
+
+// AuthorFromPath returns the owner without the "~" prefix, linked to
+// the user page on sr.ht.
+func (sourcehutHost) AuthorFromPath(r Repository) Person {
+	owner := strings.TrimPrefix(strings.SplitN(r.Path, "/", 2)[0], "~")
+	return Person{Name: owner, URL: "https://sr.ht/~" + owner}
+}
+
+func (h sourcehutHost) SourceLink(r Repository, ref, path string) string {
+	return h.RepoURL(r) + "/tree/" + ref + "/item/" + path
+}
+
+func (h sourcehutHost) ReleaseLink(r Repository, tag string) string {
+	return h.RepoURL(r) + "/refs/" + tag
+}
+
+func (h sourcehutHost) ReleasesLink(r Repository) string {
+	return h.RepoURL(r) + "/refs"
+}
+
+func (sourcehutHost) DefaultBranch() string { return "master" }
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// IndexEntry is an entry of the symbol index.
+type IndexEntry struct {
+	// Name is the symbol name, qualified by the receiver type for methods.
+	Name string
+	// Title is the text of the entry, e.g. "func (*Client) Do".
+	Title string
+	// Link is the anchor of the symbol in the README,
+	// or its documentation on pkg.go.dev if it has no section.
+	Link string
+}
+
+// Index returns the exported symbols of the package in alphabetical order,
+// linked to the sections documenting them. The links are to the headings
+// of the sections rendered by the template with the texts of the default
+// template, such as "func New" in the section "api"; the symbols whose
+// headings are not found link to pkg.go.dev.
+func (r Readme) Index() []IndexEntry {
+	var entries []IndexEntry
+
+	godocLink := func(name string) string {
+		return "https://pkg.go.dev/" + r.Pkg.ImportPath + "#" + name
+	}
+
+	add := func(name, title, section, heading string) {
+		link := godocLink(name)
+		if anchor, ok := r.anchors[[2]string{section, heading}]; ok {
+			link = "#" + anchor
+		}
+		entries = append(entries, IndexEntry{Name: name, Title: title, Link: link})
+	}
+
+	addValues := func(kind string, values []*doc.Value) {
+		heading := map[string]string{"const": "Constants", "var": "Variables"}[kind]
+		for _, v := range values {
+			for _, name := range v.Names {
+				add(name, kind+" "+name, "values", heading)
+			}
+		}
+	}
+
+	addValues("const", r.Consts())
+	addValues("var", r.Vars())
+
+	// functions associated with types are documented in the types section too
+	constructors := map[*doc.Func]bool{}
+	for _, t := range r.Pkg.Types {
+		for _, f := range t.Funcs {
+			constructors[f] = true
+		}
+	}
+
+	for _, f := range r.Funcs() {
+		title := "func " + f.Name
+		section := "api"
+		if !r.HasSection("api") && constructors[f] {
+			section = "types"
+		}
+		add(f.Name, title, section, title)
+	}
+
+	interfaces := map[*doc.Type]bool{}
+	for _, t := range r.Interfaces() {
+		interfaces[t] = true
+	}
+
+	for _, t := range r.Pkg.Types {
+		title := "type " + t.Name
+		if interfaces[t] && r.HasSection("interfaces") {
+			add(t.Name, title, "interfaces", t.Name)
+		} else {
+			add(t.Name, title, "types", title)
+		}
+
+		for _, m := range t.Methods {
+			title := "func (" + m.Recv + ") " + m.Name
+			add(t.Name+"."+m.Name, title, "types", title)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
+
+// sectionMarker marks the beginnings and the ends of sections in the output
+// rendered to collect the anchors of the headings, followed by the names
+// of the sections at the beginnings.
+const sectionMarker = "\x00section:"
+
+// sectionAnchors returns the anchors of the Markdown headings in out as
+// GitHub generates them, keyed by the names of the sections marked by
+// sectionMarker and the texts of the headings. The headings outside the
+// sections, such as the title, are keyed by the empty name.
+func sectionAnchors(out string) map[[2]string]string {
+	anchors := map[[2]string]string{}
+	s := newSlugger()
+
+	var sections []string
+	var fence string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, sectionMarker) {
+			if name := strings.TrimPrefix(line, sectionMarker); name != "" {
+				sections = append(sections, name)
+			} else if len(sections) > 0 {
+				sections = sections[:len(sections)-1]
+			}
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		heading, ok := markdownHeading(line)
+		if !ok {
+			continue
+		}
+		var section string
+		if len(sections) > 0 {
+			section = sections[len(sections)-1]
+		}
+		key := [2]string{section, heading}
+		anchor := s.slug(heading)
+		if _, ok := anchors[key]; !ok {
+			anchors[key] = anchor
+		}
+	}
+
+	return anchors
+}
+
+// markdownHeading returns the text of the ATX heading on line, such as
+// "func New" for "### func New".
+func markdownHeading(line string) (string, bool) {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || len(line) > level && line[level] != ' ' {
+		return "", false
+	}
+	text := strings.TrimSpace(line[level:])
+	// the optional closing sequence
+	if t := strings.TrimRight(text, "#"); t != text && (t == "" || strings.HasSuffix(t, " ")) {
+		text = strings.TrimSpace(t)
+	}
+	return text, true
+}
+
+// slugger generates the anchors of headings in a document.
+type slugger struct {
+	seen map[string]bool
+}
+
+func newSlugger() *slugger {
+	return &slugger{seen: map[string]bool{}}
+}
+
+// slug returns the anchor of heading as GitHub generates it,
+// suffixing "-1", "-2" and so on to the ones which appeared already.
+func (s *slugger) slug(heading string) string {
+	base := headingAnchor(heading)
+	anchor := base
+	for i := 1; s.seen[anchor]; i++ {
+		anchor = fmt.Sprintf("%s-%d", base, i)
+	}
+	s.seen[anchor] = true
+	return anchor
+}
+
+// headingAnchor returns the anchor which GitHub generates for a Markdown
+// heading: lowercased, with punctuations removed and spaces replaced by hyphens.
+func headingAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
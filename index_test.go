@@ -0,0 +1,125 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHeadingAnchor(t *testing.T) {
+	cases := map[string]string{
+		"func New":                 "func-new",
+		"func (*Client) Do":        "func-client-do",
+		"API changes since v1.2.0": "api-changes-since-v120",
+		"Über_Type 型":              "über_type-型",
+	}
+
+	for heading, anchor := range cases {
+		if got := headingAnchor(heading); got != anchor {
+			t.Errorf("headingAnchor(%q) = %q, expected %q", heading, got, anchor)
+		}
+	}
+}
+
+func TestSlugger(t *testing.T) {
+	s := newSlugger()
+
+	for _, c := range []struct {
+		heading string
+		anchor  string
+	}{
+		{"func New", "func-new"},
+		{"Foo", "foo"},
+		{"func New", "func-new-1"},
+		{"Func New!", "func-new-2"},
+		{"foo-1", "foo-1"},
+		{"Foo", "foo-2"},
+	} {
+		if got := s.slug(c.heading); got != c.anchor {
+			t.Errorf("slug(%q) = %q, expected %q", c.heading, got, c.anchor)
+		}
+	}
+}
+
+func TestReadme_Index(t *testing.T) {
+	r := testReadme(t, map[string]string{
+		"bar.go": `// Package bar does bar.
+package bar
+
+// Version is the version.
+const Version = "1.0"
+
+// Client is a client.
+type Client struct{}
+
+// New returns a Client.
+func New() *Client { return nil }
+
+// Do does something.
+func (c *Client) Do() {}
+`,
+	}, loadOptions{})
+	r.Sections = []string{"doc", "index", "api", "types", "values"}
+
+	tests := []struct {
+		overrides []string
+		expected  string
+	}{
+		{
+			nil,
+			`- [type Client](#type-client)
+- [func (*Client) Do](#func-client-do)
+- [func New](#func-new)
+- [const Version](#constants)
+`,
+		},
+		{
+			// the headings of the api section are renamed
+			[]string{`{{define "section_api"}}## Functions
+{{range .Funcs}}
+### {{.Name}}
+{{end}}{{end}}`},
+			`- [type Client](#type-client)
+- [func (*Client) Do](#func-client-do)
+- [func New](https://pkg.go.dev/foo/bar#New)
+- [const Version](#constants)
+`,
+		},
+	}
+	for _, test := range tests {
+		out, err := render(r, DefaultTemplate, test.overrides...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := strings.Index(out, "## Index\n\n")
+		if start == -1 {
+			t.Fatalf("no index in:\n%s", out)
+		}
+		index := out[start+len("## Index\n\n"):]
+		index = index[:strings.Index(index, "\n\n")+1]
+		if index != test.expected {
+			t.Errorf("index:\nGot ---\n%s\nExpected ---\n%s", index, test.expected)
+		}
+	}
+}
+
+func TestSectionAnchors(t *testing.T) {
+	out := "# bar\n\n" +
+		sectionMarker + "api\n## API\n\n### func New\n\n```sh\n# not a heading\n```\n" + sectionMarker + "\n" +
+		sectionMarker + "types\n## Types\n\n### type Client\n\n#### func New ####\n" + sectionMarker + "\n" +
+		"## API\n"
+
+	got := sectionAnchors(out)
+	expected := map[[2]string]string{
+		{"", "bar"}:              "bar",
+		{"api", "API"}:           "api",
+		{"api", "func New"}:      "func-new",
+		{"types", "Types"}:       "types",
+		{"types", "type Client"}: "type-client",
+		{"types", "func New"}:    "func-new-1",
+		{"", "API"}:              "api-1",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sectionAnchors:\nGot ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// License is the license of the package detected from the license file.
+type License struct {
+	// ID is the SPDX identifier of the license, e.g. "MIT",
+	// or "" if it could not be identified.
+	ID string
+	// Name is the name of the license file, e.g. "LICENSE".
+	Name string
+	// File is the path of the license file relative to the package directory.
+	File string
+	// Year is the year of the copyright notice, e.g. "2024" or "2018-2024".
+	Year string
+	// Holder is the copyright holder, e.g. "motemen".
+	Holder string
+}
+
+// Copyright returns the copyright notice such as "© 2024 motemen", or ""
+// if the holder is not known.
+func (l License) Copyright() string {
+	if l.Holder == "" {
+		return ""
+	}
+	if l.Year == "" {
+		return "© " + l.Holder
+	}
+	return "© " + l.Year + " " + l.Holder
+}
+
+// Badges returns the license badge if the license is identified.
+func (l License) Badges() []Badge {
+	if l.ID == "" {
+		return nil
+	}
+	return []Badge{{
+		Kind:  "license",
+		Name:  "License: " + l.ID,
+		Image: "https://img.shields.io/badge/License-" + shieldsEscape(l.ID) + "-blue.svg",
+		Link:  l.File,
+	}}
+}
+
+// licenseFileNames are the names of license files, in the order of preference.
+var licenseFileNames = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "COPYING"}
+
+// rxCopyright matches copyright notices such as
+// "Copyright (c) 2018-2024 motemen" and "Copyright 2009 The Go Authors."
+var rxCopyright = regexp.MustCompile(`(?im)^[ \t]*copyright[ \t]+(?:(?:\(c\)|©)[ \t]*)?(\d{4}(?:[ \t]*[-–,][ \t]*(?:\d{4}|present))*)?,?[ \t]*(.*)$`)
+
+// parseCopyright returns the year and the holder of the first copyright
+// notice in the license text.
+func parseCopyright(text string) (year, holder string) {
+	for _, m := range rxCopyright.FindAllStringSubmatch(text, -1) {
+		holder := strings.TrimSpace(m[2])
+		for _, suffix := range []string{"All rights reserved.", "All Rights Reserved."} {
+			holder = strings.TrimSpace(strings.TrimSuffix(holder, suffix))
+		}
+		holder = strings.TrimSuffix(holder, ".")
+		switch {
+		case holder == "", strings.HasPrefix(strings.ToLower(holder), "notice"):
+			// e.g. "copyright notice" in the license terms
+			continue
+		case strings.ContainsAny(holder[:1], "[{<"):
+			// placeholders in the appendix, e.g. "[yyyy] [name of copyright owner]"
+			continue
+		case strings.Contains(holder, "Free Software Foundation"):
+			// the copyright of the GPL texts themselves
+			continue
+		}
+		return m[1], holder
+	}
+	return "", ""
+}
+
+// licensePatterns identify licenses by the phrases in their texts, which are
+// lowercased and whitespace-normalized. The first match wins.
+var licensePatterns = []struct {
+	id      string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+}
+
+// identifyLicense returns the SPDX identifier of the license text,
+// or "" if it is not known.
+func identifyLicense(text string) string {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+
+	for _, p := range licensePatterns {
+		matched := true
+		for _, phrase := range p.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return p.id
+		}
+	}
+
+	return ""
+}
+
+// detectLicense finds the license file in dir or its parent directories up to
+// the repository root, and identifies the license. It returns nil if there is
+// no license file.
+func detectLicense(dir string) *License {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+
+	path := findFileUp(dir, licenseFileNames...)
+	if path == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return nil
+	}
+	year, holder := parseCopyright(string(b))
+	return &License{
+		ID:     identifyLicense(string(b)),
+		Name:   filepath.Base(path),
+		File:   filepath.ToSlash(rel),
+		Year:   year,
+		Holder: holder,
+	}
+}
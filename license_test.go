@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIdentifyLicense(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{
+			"MIT License\n\nCopyright (c) 2018 motemen\n\nPermission is hereby granted, free of charge, to any person obtaining a copy",
+			"MIT",
+		},
+		{
+			"                                 Apache License\n                           Version 2.0, January 2004",
+			"Apache-2.0",
+		},
+		{
+			"Redistribution and use in source and binary forms, with or without\nmodification, are permitted ...\n   * Neither the name of Google Inc. nor the names of its\ncontributors may be used",
+			"BSD-3-Clause",
+		},
+		{
+			"Redistribution and use in source and binary forms, with or without\nmodification, are permitted provided that the following conditions are met:",
+			"BSD-2-Clause",
+		},
+		{
+			"                    GNU GENERAL PUBLIC LICENSE\n                       Version 3, 29 June 2007",
+			"GPL-3.0",
+		},
+		{
+			"All rights reserved.",
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		if got := identifyLicense(test.text); got != test.expected {
+			t.Errorf("identifyLicense(%q) = %q, expected %q", test.text, got, test.expected)
+		}
+	}
+}
+
+func TestParseCopyright(t *testing.T) {
+	tests := []struct {
+		text   string
+		year   string
+		holder string
+	}{
+		{"MIT License\n\nCopyright (c) 2018 motemen\n\nPermission is hereby granted", "2018", "motemen"},
+		{"Copyright © 2018-2024 Hiroshi Motemen", "2018-2024", "Hiroshi Motemen"},
+		{"Copyright 2009 The Go Authors. All rights reserved.", "2009", "The Go Authors"},
+		{"Copyright (c) The Example Project\n", "", "The Example Project"},
+		{"The above copyright notice and this permission notice shall be included", "", ""},
+		{"                                 Apache License\n                           Version 2.0, January 2004", "", ""},
+		{"   Copyright [yyyy] [name of copyright owner]\n", "", ""},
+		{" Copyright (C) 2007 Free Software Foundation, Inc. <https://fsf.org/>\n...\n    Copyright (C) <year>  <name of author>\n", "", ""},
+	}
+
+	for _, test := range tests {
+		year, holder := parseCopyright(test.text)
+		if year != test.year || holder != test.holder {
+			t.Errorf("parseCopyright(%q) = %q, %q, expected %q, %q", test.text, year, holder, test.year, test.holder)
+		}
+	}
+}
+
+func TestDetectLicense(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "sub", "pkg")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if l := detectLicense(dir); l != nil {
+		t.Errorf("detectLicense should be nil without license files: %+v", l)
+	}
+
+	text := "Permission is hereby granted, free of charge, to any person obtaining a copy"
+	if err := ioutil.WriteFile(filepath.Join(root, "LICENSE.md"), []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := detectLicense(dir)
+	expected := &License{ID: "MIT", Name: "LICENSE.md", File: "../../LICENSE.md"}
+	if !reflect.DeepEqual(l, expected) {
+		t.Errorf("detectLicense mismatch:\nGot ---\n%+v\nExpected ---\n%+v\n", l, expected)
+	}
+
+	badges := []string{"[![License: MIT](https://img.shields.io/badge/License-MIT-blue.svg)](../../LICENSE.md)"}
+	if got := badgeMarkdowns(l.Badges()); !reflect.DeepEqual(got, badges) {
+		t.Errorf("Badges() = %q, expected %q", got, badges)
+	}
+}
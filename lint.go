@@ -0,0 +1,254 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"text/template"
+	"text/template/parse"
+)
+
+// builtinFuncs are the functions predefined by text/template.
+var builtinFuncs = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "slice": true,
+	"js": true, "len": true, "not": true, "or": true, "print": true,
+	"printf": true, "println": true, "urlquery": true,
+	"eq": true, "ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// runTemplate implements "goreadme template lint [FILE...]", which checks
+// templates against the Readme data model without generating anything.
+// Without files, the default template is checked.
+func runTemplate(args []string) error {
+	if len(args) == 0 || args[0] != "lint" {
+		return withStatus(exitUsage, fmt.Errorf("usage: goreadme template lint [FILE...]"))
+	}
+
+	flags := flag.NewFlagSet("template lint", flag.ContinueOnError)
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+
+	type source struct{ name, content string }
+
+	var sources []source
+	for _, file := range flags.Args() {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return withStatus(exitUsage, err)
+		}
+		sources = append(sources, source{file, string(b)})
+	}
+	if len(sources) == 0 {
+		sources = append(sources, source{"DefaultTemplate", DefaultTemplate})
+	}
+
+	problems := 0
+	for _, src := range sources {
+		warnings, err := lintTemplate(src.name, src.content)
+		if err != nil {
+			return withStatus(exitTemplate, err)
+		}
+		for _, w := range warnings {
+			fmt.Println(w)
+			problems++
+		}
+	}
+
+	if problems > 0 {
+		return withStatus(exitFailure, fmt.Errorf("%d problem(s) found", problems))
+	}
+
+	return nil
+}
+
+// lintTemplate parses a README template and reports references to fields
+// which do not exist in the Readme data model and to undefined functions.
+// Only syntax errors are returned as an error.
+func lintTemplate(name, content string) ([]string, error) {
+	// functions are checked while walking the tree so that undefined ones
+	// are reported as warnings
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+	if _, err := tree.Parse(content, "", "", trees); err != nil {
+		return nil, err
+	}
+
+	l := &templateLinter{
+		funcs: templateFuncs(&Readme{}),
+		root:  reflect.TypeOf(&Readme{}),
+	}
+	for _, tree := range trees {
+		l.tree = tree
+		l.walk(tree.Root, l.root)
+	}
+
+	return l.warnings, nil
+}
+
+type templateLinter struct {
+	tree     *parse.Tree
+	funcs    template.FuncMap
+	root     reflect.Type
+	warnings []string
+}
+
+func (l *templateLinter) warnf(node parse.Node, format string, args ...interface{}) {
+	location, _ := l.tree.ErrorContext(node)
+	l.warnings = append(l.warnings, location+": "+fmt.Sprintf(format, args...))
+}
+
+// walk checks node, whose dot has type dot. A nil type means
+// the type could not be determined, and fields on it are not checked.
+func (l *templateLinter) walk(node parse.Node, dot reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			l.walk(c, dot)
+		}
+	case *parse.ActionNode:
+		l.pipeType(n.Pipe, dot)
+	case *parse.IfNode:
+		l.pipeType(n.Pipe, dot)
+		l.walk(n.List, dot)
+		l.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		t := l.pipeType(n.Pipe, dot)
+		l.walk(n.List, elemType(t))
+		l.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		t := l.pipeType(n.Pipe, dot)
+		l.walk(n.List, t)
+		l.walk(n.ElseList, dot)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			l.pipeType(n.Pipe, dot)
+		}
+	}
+}
+
+func (l *templateLinter) pipeType(pipe *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+
+	var t reflect.Type
+	for _, cmd := range pipe.Cmds {
+		t = l.cmdType(cmd, dot)
+	}
+	return t
+}
+
+func (l *templateLinter) cmdType(cmd *parse.CommandNode, dot reflect.Type) reflect.Type {
+	for _, arg := range cmd.Args[1:] {
+		l.argType(arg, dot)
+	}
+
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		if builtinFuncs[ident.Ident] {
+			return nil
+		}
+		fn, ok := l.funcs[ident.Ident]
+		if !ok {
+			l.warnf(ident, "function %q not defined", ident.Ident)
+			return nil
+		}
+		if ft := reflect.TypeOf(fn); ft.NumOut() > 0 {
+			return ft.Out(0)
+		}
+		return nil
+	}
+
+	return l.argType(cmd.Args[0], dot)
+}
+
+func (l *templateLinter) argType(arg parse.Node, dot reflect.Type) reflect.Type {
+	switch n := arg.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return l.resolve(n, dot, n.Ident)
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" {
+			return l.resolve(n, l.root, n.Ident[1:])
+		}
+	case *parse.ChainNode:
+		return l.resolve(n, l.argType(n.Node, dot), n.Field)
+	case *parse.PipeNode:
+		return l.pipeType(n, dot)
+	}
+	return nil
+}
+
+func (l *templateLinter) resolve(node parse.Node, t reflect.Type, names []string) reflect.Type {
+	for _, name := range names {
+		if t == nil {
+			return nil
+		}
+		next, ok := fieldType(t, name)
+		if !ok {
+			l.warnf(node, "can't evaluate field %s in type %s", name, t)
+			return nil
+		}
+		t = next
+	}
+	return t
+}
+
+// fieldType returns the type of .name evaluated on a value of type t.
+// The returned type is nil if it cannot be determined statically.
+func fieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	if t.Kind() == reflect.Interface {
+		return nil, true
+	}
+
+	ptr := t
+	if t.Kind() != reflect.Ptr {
+		ptr = reflect.PtrTo(t)
+	}
+	if m, ok := ptr.MethodByName(name); ok {
+		if m.Type.NumOut() > 0 {
+			return m.Type.Out(0), true
+		}
+		return nil, true
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if f, ok := t.FieldByName(name); ok && f.PkgPath == "" {
+			return f.Type, true
+		}
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return t.Elem(), true
+		}
+	}
+
+	return nil, false
+}
+
+// elemType returns the type of dot inside {{range}} over a value of type t.
+func elemType(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return t.Elem()
+	case reflect.Int:
+		return t
+	}
+	return nil
+}
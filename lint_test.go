@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintTemplate(t *testing.T) {
+	cases := []struct {
+		content  string
+		warnings []string
+	}{
+		{
+			content:  DefaultTemplate,
+			warnings: nil,
+		},
+		{
+			content: `{{.Nmae}}{{range .Examples}}{{.Output|fence ""}}{{.Foo}}{{end}}{{.Author.Name|shout}}`,
+			warnings: []string{
+				"test:1:2: can't evaluate field Nmae in type *main.Readme",
+				"test:1:50: can't evaluate field Foo in type *doc.Example",
+				"test:1:78: function \"shout\" not defined",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		warnings, err := lintTemplate("test", c.content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(warnings, c.warnings) {
+			t.Errorf("lintTemplate mismatch:\nGot ---\n%q\nExpected ---\n%q\n", warnings, c.warnings)
+		}
+	}
+}
@@ -3,436 +3,1072 @@
 // a Markdown content suitable as a README boilerplate.
 //
 //   goreadme [.] > README.md
+//   goreadme -w DIR...   # write README.md in each DIR, which may be a glob like pkg/*
+//   goreadme PKG@VERSION # the package downloaded from GOPROXY, e.g. golang.org/x/mod/zip@latest
+//   goreadme URL[#REF]   # the root package of the git repository cloned shallowly
 //
 // For the default template, run `go doc github.com/motemen/goreadme.DefaultTemplate`.
+//
+// Subcommands:
+//
+//   goreadme section NAME [.]                        # render only the section NAME (e.g. examples)
+//   goreadme exports [-format text|json] [.]         # index of exported symbols
+//   goreadme release-body [-from REF] [-to REF] [.]  # release notes with API changes
+//   goreadme semver-check [-since REF] [-json] [.]   # suggest the next version
+//   goreadme template lint [FILE...]                 # check templates for unknown fields
+//
+// Exit status is 0 on success, 1 on failure, 2 on usage errors, 3 on Go
+// parse errors, 4 on template errors and 5 on git or network errors. With
+// -error-format json, the error is printed to stderr as a JSON object with
+// "error", "status" and "kind" keys.
+//
+// For import paths of custom domains such as go.uber.org/zap, the repository
+// is set by "repository" in .goreadme.yml, or looked up by their go-import
+// meta tags over the network with -resolve-vanity.
 package main
 
 // TODO(motemen): Show only toplevel todos?
 
 import (
-	"bytes"
 	"flag"
 	"fmt"
-	"html"
+	"go/doc"
 	"io/ioutil"
-	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"text/template"
+)
 
-	"go/ast"
-	"go/build"
-	"go/doc"
-	"go/parser"
-	"go/printer"
-	"go/token"
+// The default README template.
+//
+// The sections are defined as templates named "section_NAME" and rendered
+// in the order of .Sections. Templates given by "-f override=FILE" can
+// redefine them.
+var DefaultTemplate = `# {{.Name}}
+{{range .Sections}}
+{{section .}}
+{{end}}
 
-	"github.com/motemen/go-gitconfig"
-)
+{{define "section_badges"}}
+{{if (not .IsCommand)}}
+[![Go Reference](https://pkg.go.dev/badge/{{.Pkg.ImportPath}}.svg)]({{.DocURL}}){{end}}
+{{range .Badges}}{{.}}
+{{end}}
+{{end}}
 
-type Readme struct {
-	fset     *token.FileSet
-	Pkg      *doc.Package
-	Examples []*doc.Example
-	Exports  []string
-	Author   Author
-	Badges   []string
-}
+{{define "section_doc"}}
+{{.Pkg.Doc|markdown}}
+{{end}}
 
-func (r Readme) IsCommand() bool {
-	return r.Pkg.Name == "main"
-}
+{{define "section_installation"}}
+## Installation
 
-func (r Readme) Name() string {
-	if r.IsCommand() {
-		// this package should be a command
-		parts := strings.Split(r.Pkg.ImportPath, "/")
-		return parts[len(parts)-1]
-	}
+    {{.InstallCommand}}
+{{if and .IsCommand .Homebrew}}
+Or with [Homebrew](https://brew.sh/):
 
-	return r.Pkg.Name
-}
+    brew install {{if .HomebrewCask}}--cask {{end}}{{.Homebrew}}
+{{end}}
+{{- with .Downloads}}
+Or download the prebuilt binaries of {{$.Version}} from {{if $.ReleasesURL}}[the releases page]({{$.ReleasesURL}}){{else}}the releases page{{end}}:
 
-type Author struct {
-	Name  string `gitconfig:"user.name"`
-	Email string `gitconfig:"user.email"`
-	// Non-standard configuration.
-	Homepage string `gitconfig:"user.homepage"`
-}
+| OS | Arch | Archive |
+|---|---|---|
+{{range .}}| {{.OS}} | {{.Arch}} | {{if .URL}}[{{.Archive}}]({{.URL}}){{else}}{{.Archive}}{{end}} |
+{{end}}
+{{- end}}
+{{end}}
 
-var (
-	patExportedIdent = `\p{Lu}[\pL_0-9]*`
-	patPkgPath       = `(?:[-a-z0-9.:]+/)*[-a-z0-9]+`
-)
+{{define "section_binaries"}}
+{{with .Binaries}}
+## Commands
 
-var predefCodePatterns = []string{
-	"interface",
-	"struct",
-	`(?:` + patPkgPath + `\.)?` + patExportedIdent + `\.` + patExportedIdent,
-	patPkgPath + `\.` + `(?:` + patExportedIdent + `\.)?` + patExportedIdent,
-	`\(\*` + `(?:` + patPkgPath + `\.)?` + patExportedIdent + `\)\.` + patExportedIdent,
-}
+| Command | Description | Installation |
+|---|---|---|
+{{range .}}| [{{.Name}}](cmd/{{.Name}}) | {{cell .Synopsis}} | ` + "`{{.Install}}`" + ` |
+{{end}}
+{{end}}
+{{end}}
 
-func mkCodeRegexp(idents []string) *regexp.Regexp {
-	return regexp.MustCompile(
-		`(^|\s)((?:` + strings.Join(predefCodePatterns, "|") + `)` +
-			`(?:\{.*?\}|\[.*?\]|\(.*?\))?)([.,]|\s|$)`,
-	)
-}
+{{define "section_requirements"}}
+{{with .GoVersion}}
+## Requirements
 
-// The default README template.
-var DefaultTemplate = `# {{.Name}}
+Go {{.}} or later{{with $.Toolchain}} (toolchain {{.}} is recommended){{end}}.
+{{end}}
+{{end}}
 
-{{if (not .IsCommand)}}
-[![GoDoc](https://godoc.org/{{.Pkg.ImportPath}}?status.svg)](https://godoc.org/{{.Pkg.ImportPath}}){{end}}
-{{range .Badges}}{{.}}
+{{define "section_platforms"}}
+{{with .Platforms}}
+## Supported platforms
+
+| |{{range .Arches}} {{.}} |{{end}}
+|---|{{range .Arches}}:---:|{{end}}
+{{range .Rows}}| {{.OS}} |{{range .Supported}} {{if .}}✓{{end}} |{{end}}
+{{end}}
+{{end}}
 {{end}}
 
-{{.Pkg.Doc|markdown}}
+{{define "section_usage"}}
+{{if .Help}}
+## Usage
 
-{{if .IsCommand}}
-## Installation
+{{fence "" .Help}}
+{{else if .Flags}}
+## Usage
+
+| Flag | Default | Description |
+|---|---|---|
+{{range .Flags}}| ` + "`-{{.Name}}`" + ` | {{with .Default}}` + "`{{cell .}}`" + `{{end}} | {{cell .Usage}} |
+{{end}}
+{{end}}
+{{end}}
+
+{{define "section_commands"}}
+{{with .Commands}}
+## Commands
+{{range .}}
+### ` + "`{{.Path}}`" + `
+{{with .Long}}
+{{.}}
+{{else}}{{with .Short}}
+{{.}}
+{{end}}{{end}}
+{{- with .Flags}}
+| Flag | Default | Description |
+|---|---|---|
+{{range .}}| ` + "`--{{.Name}}`{{with .Shorthand}}, `-{{.}}`{{end}}" + ` | {{with .Default}}` + "`{{cell .}}`" + `{{end}} | {{cell .Usage}} |
+{{end}}
+{{- end}}
+{{- end}}
+{{end}}
+{{end}}
+
+{{define "section_configuration"}}
+{{with .ConfigFields}}
+## Configuration
+{{$env := false}}{{range .}}{{if .Env}}{{$env = true}}{{end}}{{end}}
+| Key | Type | Default |{{if $env}} Environment variable |{{end}} Description |
+|---|---|---|{{if $env}}---|{{end}}---|
+{{range .}}| ` + "`{{.Key}}`" + ` | ` + "`{{cell .Type}}`" + ` | {{with .Default}}` + "`{{cell .}}`" + `{{end}} |{{if $env}} {{with .Env}}` + "`{{.}}`" + `{{end}} |{{end}} {{cell .Description}} |
+{{end}}
+{{end}}
+{{end}}
+
+{{define "section_environment"}}
+{{with .Env}}
+## Environment variables
+
+| Variable | Default | Description |
+|---|---|---|
+{{range .}}| ` + "`{{.Name}}`" + ` | {{if .Required}}*required*{{else}}{{with .Default}}` + "`{{cell .}}`" + `{{end}}{{end}} | {{cell .Description}} |
+{{end}}
+{{end}}
+{{end}}
 
-    go get -u {{.Pkg.ImportPath}}
+{{define "section_exit-codes"}}
+{{with .ExitCodes}}
+## Exit codes
 
+| Code | Description |
+|---|---|
+{{range .}}| {{.Code}} | {{cell .Description}} |
 {{end}}
+{{end}}
+{{end}}
+
+{{define "section_docker"}}
+{{with .DockerImage}}
+## Usage with Docker
+
+{{if $.IsCommand -}}
+Run the command on the files in the current directory{{if $.Env}}, passing the environment variables it reads{{end}}:
+
+    docker run --rm -v "$PWD:/work" -w /work{{range $.Env}} -e {{.Name}}{{end}} {{.}}
+{{- else -}}
+    docker run --rm {{.}}
+{{- end}}
 
+{{end}}
+{{end}}
+
+{{define "section_examples"}}
 {{if (len .Examples)}}
 ## Examples
 {{  range .Examples}}
-### {{.Name}}
+### {{.Name|exampleTitle}}
 
 {{.|code|fence "go"}}
+{{    with playLink .}}
+[Run in Playground]({{.}})
+{{    end}}
 {{    if .Output}}
+{{      if $.CollapseOutput}}
+{{.Output|fence ""|details "Output"}}
+{{      else}}
 Output:
 
 {{.Output|fence ""}}
+{{      end}}
+{{    end}}
+{{  end}}
+{{  if .ExampleDeps}}
+Running the examples requires additional packages, which updates go.mod and go.sum:
+
+    go get{{range .ExampleDeps}} {{.}}{{end}}
+{{  end}}
+{{end}}
+{{end}}
+
+{{define "section_benchmarks"}}
+{{with .Benchmarks}}
+## Benchmarks
+
+{{range .}}- {{.Name}}{{with .Synopsis}}: {{.}}{{end}}{{with .Result}} ({{.}}){{end}}
+{{end}}
+{{end}}
+{{end}}
+
+{{define "section_fuzz"}}
+{{with .FuzzTargets}}
+## Fuzz Targets
+
+{{range .}}- {{.Name}}{{with .Synopsis}}: {{.}}{{end}}
+{{end}}
+{{end}}
+{{end}}
+
+{{define "section_coverage"}}
+{{with .Coverage}}
+## Coverage
+
+{{.}} of statements are covered by tests.
+{{end}}
+{{end}}
+
+{{define "section_index"}}
+{{with .Index}}
+## Index
+
+{{range .}}- [{{.Title}}]({{.Link}})
+{{end}}
+{{end}}
+{{end}}
+
+{{define "section_api"}}
+{{with .Funcs}}
+## API
+{{  if $.Unexported}}
+This reference includes unexported identifiers for internal use.
+{{  end}}
+{{  range .}}
+### func {{.Name}}
+
+{{.Decl|decl|fence "go"}}
+{{.Doc|markdownAt 4}}
+{{  end}}
+{{end}}
+{{end}}
+
+{{define "section_interfaces"}}
+{{with .Interfaces}}
+## Interfaces
+{{  range .}}
+### {{.Name}}
+
+{{.Doc|markdownAt 4}}
+{{.Decl|decl|fence "go"}}
+{{  end}}
+{{end}}
+{{end}}
+
+{{define "section_types"}}
+{{with .Pkg.Types}}
+## Types
+{{  range .}}
+### type {{.Name}}
+{{    $fields := fields .Decl}}
+{{    if $fields}}
+{{.Doc|markdownAt 4}}
+{{      $json := false}}{{range $fields}}{{if .JSON}}{{$json = true}}{{end}}{{end}}
+| Field | Type |{{if $json}} JSON |{{end}} Description |
+|---|---|{{if $json}}---|{{end}}---|
+{{      range $fields}}| ` + "`{{.Name}}`" + ` | ` + "`{{cell .Type}}`" + ` |{{if $json}} {{with .JSON}}` + "`{{cell .}}`" + `{{end}} |{{end}} {{cell .Doc}} |
+{{      end}}
+{{    else}}
+{{.Decl|decl|fence "go"}}
+{{.Doc|markdownAt 4}}
+{{    end}}
+{{    range .Funcs}}
+#### func {{.Name}}
+
+{{.Decl|decl|fence "go"}}
+{{.Doc|markdownAt 5}}
+{{    end}}
+{{    range .Methods}}
+#### func ({{.Recv}}) {{.Name}}
+
+{{.Decl|decl|fence "go"}}
+{{.Doc|markdownAt 5}}
 {{    end}}
 {{  end}}
 {{end}}
+{{end}}
+
+{{define "section_values"}}
+{{with .Consts}}
+## Constants
+{{  range .}}
+{{.Decl|decl|fence "go"}}
+{{.Doc|markdownAt 3}}
+{{  end}}
+{{end}}
+{{with .Vars}}
+## Variables
+{{  range .}}
+{{.Decl|decl|fence "go"}}
+{{.Doc|markdownAt 3}}
+{{  end}}
+{{end}}
+{{end}}
 
+{{define "section_api-changes"}}
+{{with .APIChanges}}
+## API changes since {{$.Since}}
+
+{{if .Empty}}None.{{else}}{{.|apidiff}}{{end}}
+{{end}}
+{{end}}
+
+{{define "section_todo"}}
 {{if .Pkg.Notes.TODO}}
 ## TODO
 
 {{range .Pkg.Notes.TODO}}- {{.Body}}{{end}}
 {{end}}
+{{end}}
+
+{{define "section_bugs"}}
+{{if .Pkg.Notes.BUG}}
+## Known Issues
+
+{{range .Pkg.Notes.BUG}}- {{.Body}}{{end}}
+{{end}}
+{{end}}
+
+{{define "section_whats-new"}}
+{{with .WhatsNew}}{{if not .Empty}}
+## What's new{{with .Since}} since {{.}}{{end}}
+{{with .Breaking}}
+### Breaking changes
+
+{{range .}}- {{.}}
+{{end}}
+{{- end}}
+{{with .Features}}
+### Features
+
+{{range .}}- {{.}}
+{{end}}
+{{- end}}
+{{with .Fixes}}
+### Bug fixes
+
+{{range .}}- {{.}}
+{{end}}
+{{- end}}
+{{end}}{{end}}
+{{end}}
+
+{{define "section_changelog"}}
+{{if or .Releases .ChangelogURL}}
+## Changelog
+{{range .Releases}}
+### {{if .URL}}[{{.Tag}}]({{.URL}}){{else}}{{.Tag}}{{end}}{{with .Date}} ({{.}}){{end}}
+{{with .Message}}
+{{.}}
+{{end}}
+{{- end}}
+{{with .ChangelogURL}}
+See [the changelog]({{.}}) for {{if $.Releases}}the older releases{{else}}the changes in each release{{end}}.
+{{end}}
+{{end}}
+{{end}}
+
+{{define "section_contributing"}}
+{{if or .ContributingPath .CodeOfConductPath}}
+## Contributing
+
+{{with .ContributingPath}}Contributions are welcome! Please read [the contributing guidelines]({{.}}) before opening issues or pull requests.
+{{end}}
+{{- with .CodeOfConductPath}}This project has adopted [a code of conduct]({{.}}). By participating, you are expected to uphold it.
+{{end}}
+{{end}}
+{{end}}
+
+{{define "section_sponsors"}}
+{{with .Sponsors}}
+## Sponsors
+
+If you find this project useful, please consider sponsoring it.
+
+{{range .}}- [{{.Name}}]({{.URL}})
+{{end}}
+{{end}}
+{{end}}
+
+{{define "section_license"}}
+{{with .License}}
+## License
+
+{{if .ID}}{{.ID}}{{with .Copyright}} {{.}}{{end}}. {{else if .Copyright}}{{.Copyright}}. {{end}}See [{{.Name}}]({{.File}}) for details.
+{{end}}
+{{end}}
+
+{{define "section_contributors"}}
+{{with .Contributors}}
+## Contributors
+
+{{if (index . 0).Avatar -}}
+<p>
+{{range .}}<a href="{{.URL}}"><img src="{{.Avatar}}" width="64" height="64" alt="{{.Name}}" title="{{.Name}}"></a>
+{{end -}}
+</p>
+{{else -}}
+{{range .}}- {{.Name}}
+{{end}}
+{{- end}}
+{{end}}
+{{end}}
 
+{{define "section_author"}}
+{{if .Maintainers}}
+## Maintainers
+
+{{range .Maintainers}}- {{.Name}}{{if .URL}} <{{.URL}}>{{else if .Email}} <{{.Email}}>{{end}}
+{{end}}
+{{else if .Authors}}
+## Authors
+
+{{range .Authors}}- {{.Name}}{{if .URL}} <{{.URL}}>{{else if .Email}} <{{.Email}}>{{end}}
+{{end}}
+{{else if .Author.Name}}
 ## Author
 
-{{.Author.Name}} <{{if .Author.Homepage}}{{.Author.Homepage}}{{else}}{{.Author.Email}}{{end}}>
+{{.Author.Name}}{{if .Author.URL}} <{{.Author.URL}}>{{else if .Author.Email}} <{{.Author.Email}}>{{end}}
+{{end}}
+{{end}}
 `
 
-func main() {
-	tmplFile := flag.String("f", "", "template file")
-	flag.Parse()
+// templateFiles is the value of the -f flag, which may be given multiple times.
+type templateFiles []string
 
-	dir := "."
+func (f *templateFiles) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *templateFiles) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
 
-	args := flag.Args()
+// load reads the templates. A value "FILE" or "base=FILE" replaces the
+// base template, and "override=FILE" adds a template whose definitions
+// override blocks in the base. FILE "default" stands for DefaultTemplate.
+func (f templateFiles) load() (base string, overrides []string, err error) {
+	read := func(file string) (string, error) {
+		if file == "default" {
+			return DefaultTemplate, nil
+		}
+		b, err := ioutil.ReadFile(file)
+		return string(b), err
+	}
+
+	base = DefaultTemplate
+	for _, v := range f {
+		if strings.HasPrefix(v, "override=") {
+			content, err := read(strings.TrimPrefix(v, "override="))
+			if err != nil {
+				return "", nil, err
+			}
+			overrides = append(overrides, content)
+		} else {
+			base, err = read(strings.TrimPrefix(v, "base="))
+			if err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	return base, overrides, nil
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		exit(err)
+	}
+}
+
+func run(args []string) error {
 	if len(args) >= 1 {
-		dir = args[0]
+		switch args[0] {
+		case "section":
+			return runSection(args[1:])
+		case "exports":
+			return runExports(args[1:])
+		case "release-body":
+			return runReleaseBody(args[1:])
+		case "semver-check":
+			return runSemverCheck(args[1:])
+		case "template":
+			return runTemplate(args[1:])
+		}
+	}
+
+	return runGenerate(args)
+}
+
+// generateFlags are the flags of the commands rendering templates.
+type generateFlags struct {
+	tmplFiles    templateFiles
+	headingLevel int
+	since        string
+	sections     string
+	allDecls     bool
+	allMethods   bool
+	unexported   bool
+	fence        bool
+	fenceLang    string
+	linkIdents   bool
+	wrap         int
+	eol          string
+	collapse     string
+	collapseOut  bool
+	examples     string
+	exampleOrder string
+	playground   bool
+	exampleCode  string
+	benchResults string
+	coverage     bool
+	coverProfile string
+	execHelp     bool
+	fieldTables  bool
+	writeFile    bool
+	tags         string
+	noCgo        bool
+	goos         string
+	goarch       string
+	configStruct string
+	docSite      string
+	badgeOrder   string
+	badgeStyle   string
+	noBadges     string
+	branch       string
+	vanity       bool
+	authorName   string
+	authorURL    string
+	authorEmail  string
+	contribMin   int
+	changelogN   int
+}
+
+func (g *generateFlags) register(flags *flag.FlagSet) {
+	flags.Var(&g.tmplFiles, "f", "template `FILE`; \"override=FILE\" redefines blocks of the base template (repeatable)")
+	flags.IntVar(&g.headingLevel, "heading-level", 1, "level of the top heading; sections are one level below")
+	flags.StringVar(&g.since, "since", "", "git revision to show API changes since (e.g. v1.2.0)")
+	flags.StringVar(&g.tags, "tags", "", "comma-separated build tags; only the files satisfying the build constraints with them are documented (default: all files)")
+	flags.StringVar(&g.goos, "goos", "", "target `GOOS` selecting the platform-specific files such as _windows.go (default: all files, or the current platform with -tags)")
+	flags.StringVar(&g.goarch, "goarch", "", "target `GOARCH` selecting the platform-specific files such as _arm64.go (default: all files, or the current platform with -tags)")
+	flags.BoolVar(&g.noCgo, "no-cgo", false, "document the package as built with CGO_ENABLED=0, skipping the files importing \"C\" (default: skip the files for builds without cgo instead)")
+	flags.BoolVar(&g.allDecls, "all-decls", false, "document all declarations, not only exported ones")
+	flags.BoolVar(&g.unexported, "unexported", false, "document unexported symbols too, e.g. for internal packages (implies -all-decls and -all-methods)")
+	flags.BoolVar(&g.allMethods, "all-methods", false, "document all methods including the ones of embedded fields")
+	flags.BoolVar(&g.fence, "fence", false, "render code blocks in doc comments as fenced code blocks")
+	flags.StringVar(&g.fenceLang, "fence-lang", "", "language tag of fenced code blocks (e.g. go), or \"auto\" to detect go, sh or json; implies -fence")
+	flags.BoolVar(&g.fieldTables, "field-tables", false, "render struct types as tables of their fields instead of code blocks")
+	flags.BoolVar(&g.linkIdents, "link-idents", false, "link identifiers in doc comments to pkg.go.dev")
+	flags.IntVar(&g.wrap, "wrap", -1, "wrap paragraphs of doc comments at `N` columns, or not at all if 0 (default: keep line breaks)")
+	flags.StringVar(&g.eol, "eol", "auto", "line ending: lf, crlf or auto to follow .gitattributes or .editorconfig")
+	flags.StringVar(&g.collapse, "collapse", "", "comma-separated sections to collapse in <details> blocks (e.g. api,todo)")
+	flags.BoolVar(&g.collapseOut, "collapse-output", false, "collapse the outputs of examples in <details> blocks")
+	flags.StringVar(&g.examples, "examples", "", "include only the examples whose function names match `REGEXP` (e.g. 'Example(New|Client).*')")
+	flags.StringVar(&g.exampleOrder, "example-order", "", "order of examples: name or source (default name)")
+	flags.BoolVar(&g.playground, "playground", false, "share playable examples on the Go Playground and link to them")
+	flags.StringVar(&g.exampleCode, "example-code", "", "how to render examples: file for whole programs if playable, or body for function bodies (default file)")
+	flags.StringVar(&g.benchResults, "bench-results", "", "output of \"go test -bench\" to show in the benchmarks section")
+	flags.BoolVar(&g.coverage, "coverage", false, "run \"go test -cover\" to show the test coverage")
+	flags.BoolVar(&g.execHelp, "exec-help", false, "build the command and show its -h output in the usage section")
+	flags.StringVar(&g.configStruct, "config-struct", "", "`NAME` of the configuration struct to document in the configuration section (default: the one marked by //goreadme:config)")
+	flags.StringVar(&g.coverProfile, "coverprofile", "", "coverage profile `FILE` to show the test coverage from, instead of running tests")
+	flags.StringVar(&g.docSite, "doc-site", "", "`URL` of the documentation site the reference badge links to (default https://pkg.go.dev)")
+	flags.StringVar(&g.badgeOrder, "badge-order", "", "comma-separated kinds of badges to show first, in order (e.g. release,actions)")
+	flags.StringVar(&g.noBadges, "disable-badges", "", "comma-separated kinds of badges not to detect (e.g. travis,release)")
+	flags.StringVar(&g.branch, "branch", "", "`BRANCH` the badges show the status of (default: the default branch of origin)")
+	flags.BoolVar(&g.vanity, "resolve-vanity", false, "look up the repository of a custom-domain import path by its go-import meta tags over the network")
+	flags.StringVar(&g.badgeStyle, "badge-style", "", "style of shields.io badges: flat, flat-square, plastic, for-the-badge or social")
+	flags.StringVar(&g.authorName, "author-name", "", "name of the author (default: user.name of git config)")
+	flags.StringVar(&g.authorURL, "author-url", "", "`URL` of the author (default: user.homepage of git config)")
+	flags.StringVar(&g.authorEmail, "author-email", "", "email of the author (default: user.email of git config)")
+	flags.IntVar(&g.contribMin, "contributors-min-commits", 0, "list only the contributors with at least `N` commits in the contributors section")
+	flags.IntVar(&g.changelogN, "changelog-tags", 0, "show the latest `N` annotated tags with their messages in the changelog section")
+	flags.StringVar(&g.sections, "sections", "", "comma-separated sections to generate in order, or to omit if prefixed by \"-\" (e.g. -author,-todo)")
+}
+
+// docMode returns the mode to compute the package documentation with.
+func (g *generateFlags) docMode() doc.Mode {
+	var mode doc.Mode
+	if g.allDecls || g.unexported {
+		mode |= doc.AllDecls
+	}
+	if g.allMethods || g.unexported {
+		mode |= doc.AllMethods
 	}
+	return mode
+}
+
+// pkgSource is the local directory of the package to generate the README
+// of.
+type pkgSource struct {
+	dir string
+	// remote reports whether dir is a temporary copy of the package
+	// downloaded from the module proxy, of the module version moduleVersion,
+	// or cloned from a git URL.
+	remote        bool
+	moduleVersion string
+	tmp           string
+}
 
-	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+// close removes the temporary directory of the remote package.
+func (s *pkgSource) close() {
+	if s.tmp != "" {
+		os.RemoveAll(s.tmp)
+	}
+}
+
+// fetch returns the source of the package in dir, or of the package
+// downloaded from the module proxy if dir is "PATH@VERSION" or cloned if
+// it is a git URL. The caller should close it after writing the README.
+func (g *generateFlags) fetch(dir string) (*pkgSource, error) {
+	if !isGitURL(dir) && !isRemotePackage(dir) {
+		return &pkgSource{dir: dir}, nil
+	}
+
+	if g.writeFile {
+		// it would be written into the temporary directory
+		return nil, withStatus(exitUsage, fmt.Errorf("-w cannot write the README of %s", dir))
+	}
+	if g.execHelp {
+		// it would build and run the code just downloaded
+		return nil, withStatus(exitUsage, fmt.Errorf("-exec-help cannot run the command of %s", dir))
+	}
+
+	src := &pkgSource{remote: true}
+	var err error
+	if isGitURL(dir) {
+		src.tmp, src.dir, err = cloneRepository(dir)
+	} else {
+		src.tmp, src.dir, src.moduleVersion, err = fetchPackage(dir)
+	}
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	return src, nil
+}
 
-	wd, err := os.Getwd()
+// load collects the README data of the package of src and parses the
+// templates.
+func (g *generateFlags) load(src *pkgSource) (*Readme, *template.Template, error) {
+	dir := src.dir
+	conf, err := loadConfig(dir)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, withStatus(exitUsage, err)
+	}
+
+	opts := loadOptions{
+		Mode:            g.docMode(),
+		ExcludeExamples: conf.ExcludeExamples,
+		ExampleOrder:    conf.ExampleOrder,
+		ConfigStruct:    conf.ConfigStruct,
+		DockerImage:     conf.DockerImage,
+		Badges:          conf.Badges,
+		BadgeOrder:      conf.BadgeOrder,
+		DisableBadges:   conf.DisableBadges,
+		Branch:          conf.Branch,
+		SelfHosted:      conf.selfHosted(),
+		RepositoryURL:   conf.Repository,
+		ResolveVanity:   g.vanity,
+		BadgeStyle:      conf.BadgeStyle,
+		Remote:          src.remote,
+		ModuleVersion:   src.moduleVersion,
+	}
+	if g.badgeOrder != "" {
+		opts.BadgeOrder = splitList(g.badgeOrder)
+	}
+	if g.noBadges != "" {
+		opts.DisableBadges = splitList(g.noBadges)
+	}
+	if g.badgeStyle != "" {
+		opts.BadgeStyle = g.badgeStyle
+	}
+	if g.branch != "" {
+		opts.Branch = g.branch
+	}
+	if err := checkBadgeOptions(opts.BadgeOrder, opts.DisableBadges, opts.BadgeStyle); err != nil {
+		return nil, nil, withStatus(exitUsage, err)
+	}
+	if g.exampleOrder != "" {
+		opts.ExampleOrder = g.exampleOrder
+	}
+	if g.configStruct != "" {
+		opts.ConfigStruct = g.configStruct
+	}
+	if g.tags != "" {
+		opts.Tags = splitList(g.tags)
 	}
+	opts.GOOS, opts.GOARCH = g.goos, g.goarch
+	opts.NoCgo = g.noCgo
 
-	bpkg, err := build.ImportDir(filepath.Join(wd, dir), build.FindOnly)
+	examples := conf.Examples
+	if g.examples != "" {
+		examples = g.examples
+	}
+	if examples != "" {
+		opts.Examples, err = examplesRegexp(examples)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	r, err := loadReadme(dir, opts)
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	r := Readme{}
-
-	for name, pkg := range pkgs {
-		exs := doc.Examples(pkgFiles(pkg)...)
-		for _, ex := range exs {
-			// Use the doc (if any)
-			if ex.Name == "" && ex.Doc != "" {
-				ex.Name = strings.TrimSpace(strings.TrimPrefix(ex.Doc, "Example:"))
-				if ex.Play != nil {
-					// If the example was whole file, remove the doc comment from the example function
-					for _, d := range ex.Play.Decls {
-						if f, ok := d.(*ast.FuncDecl); ok && f.Name.Name == "main" {
-							for i, c := range ex.Comments {
-								if c.Text() == ex.Doc {
-									ex.Comments = append(ex.Comments[0:i], ex.Comments[i+1:len(ex.Comments)]...)
-									break
-								}
-							}
-						}
-					}
-				}
-			}
-			r.Examples = append(r.Examples, ex)
+		return nil, nil, err
+	}
+
+	if g.playground {
+		if err := r.sharePlayableExamples(); err != nil {
+			return nil, nil, err
 		}
+	}
 
-		if strings.HasSuffix(name, "_test") {
-			continue
+	exampleCode := conf.ExampleCode
+	if g.exampleCode != "" {
+		exampleCode = g.exampleCode
+	}
+	switch exampleCode {
+	case "", "file":
+	case "body":
+		r.exampleBody = true
+	default:
+		return nil, nil, withStatus(exitUsage, fmt.Errorf("invalid -example-code: %q", exampleCode))
+	}
+
+	benchResults := conf.BenchResults
+	if g.benchResults != "" {
+		benchResults = g.benchResults
+	}
+	if benchResults != "" {
+		results, err := readBenchResults(benchResults)
+		if err != nil {
+			return nil, nil, withStatus(exitUsage, err)
+		}
+		for i, b := range r.Benchmarks {
+			r.Benchmarks[i].Result = results[b.Name]
+		}
+	}
+
+	if g.execHelp && r.IsCommand() && r.HasSection("usage") {
+		r.Help, err = runHelp(dir, r.Name())
+		if err != nil {
+			return nil, nil, err
 		}
+	}
 
-		if r.Pkg == nil {
-			r.Pkg = doc.New(pkg, bpkg.ImportPath, doc.Mode(0))
+	if g.coverProfile != "" || g.coverage {
+		var coverage float64
+		if g.coverProfile != "" {
+			coverage, err = readCoverProfile(g.coverProfile)
+		} else {
+			coverage, err = runCoverage(dir)
+		}
+		if err != nil {
+			return nil, nil, err
 		}
+		r.Coverage = fmt.Sprintf("%.1f%%", coverage)
 	}
 
-	if r.Pkg == nil {
-		log.Fatal("no source found")
+	r.DocSite = conf.DocSite
+	if g.docSite != "" {
+		r.DocSite = g.docSite
 	}
 
-	for _, v := range append(r.Pkg.Consts, r.Pkg.Vars...) {
-		r.Exports = append(r.Exports, v.Names...)
+	for _, v := range []struct {
+		field      *string
+		conf, flag string
+	}{
+		{&r.Author.Name, conf.Author.Name, g.authorName},
+		{&r.Author.URL, conf.Author.URL, g.authorURL},
+		{&r.Author.Email, conf.Author.Email, g.authorEmail},
+	} {
+		if v.conf != "" {
+			*v.field = v.conf
+		}
+		if v.flag != "" {
+			*v.field = v.flag
+		}
+		if v.conf != "" || v.flag != "" {
+			// the author given explicitly takes precedence over AUTHORS
+			r.Authors = nil
+		}
 	}
 
-	for _, f := range r.Pkg.Funcs {
-		r.Exports = append(r.Exports, f.Name)
+	if len(conf.Maintainers) > 0 {
+		r.Maintainers = conf.Maintainers
 	}
 
-	for _, t := range r.Pkg.Funcs {
-		r.Exports = append(r.Exports, t.Name)
+	if conf.Homebrew != "" {
+		r.Homebrew, r.HomebrewCask = conf.Homebrew, false
 	}
 
-	// Collect badges
-	if _, err := os.Stat(filepath.Join(bpkg.Dir, ".travis.yml")); err == nil {
-		if strings.HasPrefix(bpkg.ImportPath, "github.com/") {
-			// [![Build Status](https://travis-ci.org/motemen/go-sqlf.svg?branch=master)](https://travis-ci.org/motemen/go-sqlf)
-			branch := "master"
+	r.Snippets = conf.Snippets
+	r.Unexported = g.unexported
+	r.markdown.Fence = g.fence || g.fenceLang != ""
+	r.markdown.FenceLang = g.fenceLang
+	r.markdown.LinkIdents = g.linkIdents
+	r.markdown.Reflow = g.wrap >= 0
+	r.markdown.Wrap = g.wrap
+
+	sectionNames := conf.Sections
+	if g.sections != "" {
+		sectionNames = splitList(g.sections)
+	}
+	r.Sections, err = parseSections(sectionNames)
+	if err != nil {
+		return nil, nil, withStatus(exitUsage, err)
+	}
 
-			path := bpkg.ImportPath[len("github.com/"):]
-			cmd := exec.Command("git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
-			cmd.Dir = bpkg.Dir
-			if out, err := cmd.CombinedOutput(); err != nil {
-				b := strings.TrimSpace(string(out))
-				if strings.HasPrefix(b, "origin/") {
-					branch = b[len("origin/"):]
-				}
-			}
+	r.Collapse = conf.Collapse
+	if g.collapse != "" {
+		r.Collapse = splitList(g.collapse)
+	}
+	if err := checkSections(r.Collapse); err != nil {
+		return nil, nil, withStatus(exitUsage, err)
+	}
+	r.CollapseOutput = conf.CollapseOutput || g.collapseOut
+	r.FieldTables = conf.FieldTables || g.fieldTables
 
-			r.Badges = append(r.Badges, fmt.Sprintf(
-				"[![Build Status](https://travis-ci.org/%s.svg?branch=%s)](https://travis-ci.org/%s)",
-				path, branch, path,
-			))
+	if r.HasSection("contributors") {
+		minCommits := conf.ContributorsMinCommits
+		if g.contribMin > 0 {
+			minCommits = g.contribMin
 		}
+		contributors, err := loadContributors(dir, r.Repository, minCommits)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.Contributors = contributors
 	}
 
-	_ = gitconfig.Config{
-		Source: gitconfig.SourceDefault,
-		Dir:    bpkg.Dir,
-	}.Load(&r.Author)
-
-	tmpl := template.New("readme").Funcs(template.FuncMap{
-		"code": func(v interface{}) string {
-			s, err := renderCode(fset, v)
-			if err != nil {
-				panic(err)
-			}
-			return s
-		},
-		"markdown": func(d string) string {
-			return renderMarkdown(d, r.Exports)
-		},
-		"fence": func(ft, s string) string {
-			if !strings.HasSuffix(s, "\n") {
-				s = s + "\n"
+	if n := conf.ChangelogTags; (n > 0 || g.changelogN > 0) && r.HasSection("changelog") {
+		if g.changelogN > 0 {
+			n = g.changelogN
+		}
+		releases, err := tagReleases(dir, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range releases {
+			if r.Repository != nil {
+				releases[i].URL = r.Repository.Forge().ReleaseLink(*r.Repository, releases[i].Tag)
 			}
-			return "```" + ft + "\n" + s + "```\n"
-		},
-	})
+		}
+		r.Releases = releases
+	}
 
-	tmplContent := DefaultTemplate
-	if *tmplFile != "" {
-		b, err := ioutil.ReadFile(*tmplFile)
+	if r.HasSection("whats-new") {
+		since := g.since
+		if since == "" {
+			// all the history if there are no tags
+			since, _ = latestTag(dir, "HEAD")
+		}
+		notes, err := releaseNotes(dir, since)
 		if err != nil {
-			log.Fatal(err)
+			return nil, nil, err
 		}
-		tmplContent = string(b)
+		r.WhatsNew = notes
 	}
 
-	template.Must(tmpl.Parse(tmplContent))
+	if g.since != "" {
+		diff, err := diffAPIBetween(dir, g.since, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		r.Since = g.since
+		r.APIChanges = &diff
+	}
 
-	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, r)
+	base, overrides, err := g.tmplFiles.load()
+	if err != nil {
+		return nil, nil, withStatus(exitUsage, err)
+	}
 
+	tmpl, err := parseTemplate(r, base, overrides...)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
 	}
 
-	// drop successive empty lines
-	os.Stdout.WriteString(squeezeEmptyLines(buf.String()))
+	return r, tmpl, nil
 }
 
-func pkgFiles(pkg *ast.Package) []*ast.File {
-	ff := make([]*ast.File, 0, len(pkg.Files))
-	for _, f := range pkg.Files {
-		ff = append(ff, f)
-	}
-	return ff
+// examplesRegexp compiles the pattern of -examples, which should match
+// the whole names of example functions.
+func examplesRegexp(pattern string) (*regexp.Regexp, error) {
+	rx, err := regexp.Compile("^(?:" + pattern + ")$")
+	return rx, withStatus(exitUsage, err)
 }
 
-var (
-	rxParseHTML = regexp.MustCompile(
-		strings.Join(
-			[]string{
-				`<pre\b[^>]*>(?P<pre>(?s:.)*?)</pre>`,
-				`<h3\b[^>]*>(?P<heading>(?s:.)*?)</h3>`,
-				`<p\b[^>]*>\n?(?P<para>(?s:.)*?)</p>`,
-			},
-			"|",
-		),
-	)
-	rxStripTag = regexp.MustCompile(`<[^>]*>`)
-)
-
-func renderMarkdown(docString string, idents []string) string {
-	var out bytes.Buffer
+// write writes the output rendered for the package in dir to stdout, or
+// to README.md in dir with -w.
+func (g *generateFlags) write(dir, out string) error {
+	eol, err := lineEnding(dir, g.eol)
+	if err != nil {
+		return err
+	}
 
-	rxCode := mkCodeRegexp(idents)
+	out = shiftHeadings(out, g.headingLevel-1)
+	if eol != "\n" {
+		out = strings.Replace(out, "\n", eol, -1)
+	}
 
-	var docHTML string
-	{
-		var bufHTML bytes.Buffer
-		doc.ToHTML(&bufHTML, docString, nil)
-		docHTML = bufHTML.String()
+	if g.writeFile {
+		return ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte(out), 0644)
 	}
+	_, err = os.Stdout.WriteString(out)
+	return err
+}
 
-	for {
-		m := rxParseHTML.FindStringSubmatchIndex(docHTML)
-		if m == nil {
-			break
-		}
+// runGenerate generates the READMEs of the packages in the directories given
+// by args, which may be glob patterns.
+func runGenerate(args []string) error {
+	flags := flag.NewFlagSet("goreadme", flag.ContinueOnError)
 
-		for i, name := range rxParseHTML.SubexpNames() {
-			if i == 0 {
-				continue
-			}
-			if m[i*2] == -1 {
-				continue
-			}
+	var g generateFlags
+	g.register(flags)
+	flags.BoolVar(&g.writeFile, "w", false, "write README.md in the directories instead of stdout")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
 
-			s := html.UnescapeString(rxStripTag.ReplaceAllString(docHTML[m[i*2]:m[i*2+1]], ""))
-
-			if name == "pre" {
-				lines := strings.SplitAfter(s, "\n")
-				for i, line := range lines {
-					if i == len(lines)-1 && line == "" {
-						// nop
-					} else {
-						out.WriteString("    ")
-					}
-					out.WriteString(line)
-				}
-				out.WriteString("\n")
-			} else if name == "heading" {
-				out.WriteString("## ")
-				out.WriteString(s)
-				out.WriteString("\n\n")
-			} else {
-				s = rxCode.ReplaceAllString(s, "$1`$2`$3")
-				s = regexp.MustCompile(`[_]`).ReplaceAllString(s, `\_`)
-				out.WriteString(s)
-				out.WriteString("\n")
+	dirs, err := expandDirs(flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(dirs) > 1 && !g.writeFile {
+		return withStatus(exitUsage, fmt.Errorf("multiple directories require -w"))
+	}
+
+	for _, dir := range dirs {
+		if err := g.generate(dir); err != nil {
+			if len(dirs) > 1 {
+				err = fmt.Errorf("%s: %w", dir, err)
 			}
+			return err
 		}
-
-		docHTML = docHTML[m[1]:]
 	}
-
-	return out.String()
+	return nil
 }
 
-var rxOutputPrefix = regexp.MustCompile(`(?i)^[[:space:]]*output:`)
+// generate renders the README of the package in dir and writes it.
+func (g *generateFlags) generate(dir string) error {
+	src, err := g.fetch(dir)
+	if err != nil {
+		return err
+	}
+	defer src.close()
+
+	r, tmpl, err := g.load(src)
+	if err != nil {
+		return err
+	}
 
-func renderCode(fset *token.FileSet, v interface{}) (string, error) {
-	printerConfig := printer.Config{
-		Tabwidth: 4,
-		Mode:     printer.UseSpaces,
+	out, err := execute(tmpl, "readme", r)
+	if err != nil {
+		return err
 	}
 
-	var buf bytes.Buffer
+	return g.write(src.dir, out)
+}
 
-	if node, ok := v.(ast.Node); ok {
-		var err error
-		if block, ok := node.(*ast.BlockStmt); ok {
-			err = printerConfig.Fprint(&buf, fset, block.List)
-		} else {
-			err = printerConfig.Fprint(&buf, fset, node)
-		}
-		return buf.String(), err
+// expandDirs returns the directories given by args, expanding the glob
+// patterns among them to the directories of Go packages. It returns the
+// current directory if args is empty.
+func expandDirs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{"."}, nil
 	}
 
-	if ex, ok := v.(*doc.Example); ok {
-		// Try to remove "Output:" comments
-		comments := make([]*ast.CommentGroup, 0, len(ex.Comments))
-		var outputComment *ast.CommentGroup
-		for _, c := range ex.Comments {
-			if rxOutputPrefix.MatchString(c.Text()) {
-				outputComment = c
-				continue
-			}
-			comments = append(comments, c)
+	var dirs []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			dirs = append(dirs, arg)
+			continue
 		}
 
-		if f := ex.Play; f != nil {
-			for _, d := range f.Decls {
-				if fun, ok := d.(*ast.FuncDecl); ok && fun.Name.Name == "main" {
-					if fun.Pos() <= outputComment.Pos() && outputComment.Pos() <= fun.End() {
-						fun.Body.Rbrace = fun.Body.List[len(fun.Body.List)-1].End()
-					}
-				}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, withStatus(exitUsage, fmt.Errorf("invalid pattern %q: %v", arg, err))
+		}
+		n := len(dirs)
+		for _, m := range matches {
+			if goFiles, _ := filepath.Glob(filepath.Join(m, "*.go")); len(goFiles) > 0 {
+				dirs = append(dirs, m)
 			}
+		}
+		if len(dirs) == n {
+			return nil, withStatus(exitUsage, fmt.Errorf("no package directories match %q", arg))
+		}
+	}
+	return dirs, nil
+}
 
-			node := printer.CommentedNode{
-				Node:     f,
-				Comments: comments,
-			}
-			err := printerConfig.Fprint(&buf, fset, &node)
-			return buf.String(), err
-		} else if block, ok := ex.Code.(*ast.BlockStmt); ok {
-			// XXX dirty hack: we need BlockStmt code without indentation;
-			// so here we make a fake "switch" statement and remove the
-			// outermost braces.
-			node := printer.CommentedNode{
-				Node:     &ast.SwitchStmt{Body: block},
-				Comments: comments,
-			}
+// runSection implements "goreadme section NAME [dir]", which renders only
+// the section NAME, that is, the template "section_NAME".
+func runSection(args []string) error {
+	flags := flag.NewFlagSet("section", flag.ContinueOnError)
 
-			var b bytes.Buffer
+	var g generateFlags
+	g.register(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
 
-			err := printerConfig.Fprint(&b, fset, &node)
-			if err != nil {
-				return "", err
-			}
+	if flags.NArg() < 1 {
+		return withStatus(exitUsage, fmt.Errorf("usage: goreadme section NAME [dir]"))
+	}
 
-			s := b.String()
-			if strings.HasPrefix(s, "switch {\n") && strings.HasSuffix(s, "\n}") {
-				s = s[len("switch {\n") : len(s)-len("\n}")]
-				return s, nil
-			}
-		}
+	name := flags.Arg(0)
 
-		node := printer.CommentedNode{
-			Node:     ex.Code,
-			Comments: comments,
-		}
-		err := printerConfig.Fprint(&buf, fset, &node)
-		return buf.String(), err
+	dir := "."
+	if flags.NArg() >= 2 {
+		dir = flags.Arg(1)
 	}
 
-	return "", fmt.Errorf("cannot handle %T", v)
-}
+	src, err := g.fetch(dir)
+	if err != nil {
+		return err
+	}
+	defer src.close()
 
-var rxEmptyLines = regexp.MustCompile(`\n{3,}`)
+	r, tmpl, err := g.load(src)
+	if err != nil {
+		return err
+	}
+
+	out, err := execute(tmpl, "section_"+name, r)
+	if err != nil {
+		return err
+	}
 
-func squeezeEmptyLines(s string) string {
-	return rxEmptyLines.ReplaceAllString(s, "\n\n")
+	return g.write(src.dir, strings.TrimLeft(out, "\n"))
 }
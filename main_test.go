@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandDirs(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for name, content := range map[string]string{
+		"pkg/a/a.go":          "// Package a is a.\npackage a\n",
+		"pkg/b/b.go":          "// Package b is b.\npackage b\n",
+		"pkg/testdata/x.txt":  "",
+		"pkg/README.md":       "",
+		"other/c/c.go":        "package c\n",
+		"other/c/c_extra.txt": "",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{nil, []string{"."}},
+		{[]string{"x", "y"}, []string{"x", "y"}},
+		{[]string{"pkg/*", "other/c"}, []string{"pkg/a", "pkg/b", "other/c"}},
+	}
+	for _, test := range tests {
+		var args []string
+		for _, arg := range test.args {
+			args = append(args, filepath.Join(root, arg))
+		}
+		var expected []string
+		for _, dir := range test.expected {
+			expected = append(expected, filepath.Join(root, dir))
+		}
+		if test.args == nil {
+			args, expected = nil, test.expected
+		}
+
+		got, err := expandDirs(args)
+		if err != nil {
+			t.Errorf("expandDirs(%q): %v", test.args, err)
+		} else if !reflect.DeepEqual(got, expected) {
+			t.Errorf("expandDirs(%q) = %q, expected %q", test.args, got, expected)
+		}
+	}
+
+	if _, err := expandDirs([]string{filepath.Join(root, "none/*")}); err == nil {
+		t.Errorf("expandDirs should fail if nothing matches")
+	}
+
+	if err := runGenerate([]string{"-sections", "doc", filepath.Join(root, "pkg/*")}); err == nil {
+		t.Errorf("runGenerate should fail for multiple directories without -w")
+	}
+}
+
+func TestGenerateFlags_fetchRemote(t *testing.T) {
+	tests := []struct {
+		g   generateFlags
+		dir string
+	}{
+		{generateFlags{writeFile: true}, "https://example.invalid/foo.git"},
+		{generateFlags{writeFile: true}, "example.invalid/foo@v1.0.0"},
+		{generateFlags{execHelp: true}, "https://example.invalid/foo.git"},
+		{generateFlags{execHelp: true}, "example.invalid/foo@v1.0.0"},
+	}
+
+	for _, test := range tests {
+		_, err := test.g.fetch(test.dir)
+		var e *exitError
+		if !errors.As(err, &e) || e.status != exitUsage {
+			t.Errorf("fetch(%q) with %+v = %v, expected a usage error", test.dir, test.g, err)
+		}
+	}
+}
+
+// captureStdout returns what f writes to os.Stdout.
+func captureStdout(t *testing.T, f func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	err = f()
+	os.Stdout = stdout
+	w.Close()
+
+	b, readErr := ioutil.ReadAll(r)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return string(b), err
+}
+
+func TestRunSection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"go.mod": "module foo/bar\n",
+		"bar.go": "// Package bar does bar.\npackage bar\n\n// TODO(alice): do baz\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+		status   int
+	}{
+		{[]string{"doc", dir}, "Package bar does bar.\n\n", exitOK},
+		{[]string{"-heading-level", "2", "todo", dir}, "### TODO\n\n- do baz\n\n", exitOK},
+		{[]string{"nope", dir}, "", exitUsage},
+		{[]string{}, "", exitUsage},
+	}
+	for _, test := range tests {
+		got, err := captureStdout(t, func() error { return runSection(test.args) })
+		status := exitOK
+		var e *exitError
+		if errors.As(err, &e) {
+			status = e.status
+		} else if err != nil {
+			status = exitFailure
+		}
+		if status != test.status {
+			t.Errorf("runSection(%q) = %v, expected status %d", test.args, err, test.status)
+		}
+		if got != test.expected {
+			t.Errorf("runSection(%q):\nGot ---\n%q\nExpected ---\n%q", test.args, got, test.expected)
+		}
+	}
+}
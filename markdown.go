@@ -0,0 +1,543 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/doc"
+	"go/doc/comment"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// Scripts which do not separate words by spaces. Identifiers are not
+	// expected to contain them so that they can be found in such prose.
+	patNoSpaceScripts = `\p{Han}\p{Hiragana}\p{Katakana}\p{Hangul}\p{Thai}`
+
+	patExportedIdent = `\p{Lu}(?:[^\P{L}` + patNoSpaceScripts + `]|[\p{Nd}_])*`
+	patPkgPath       = `(?:[-a-z0-9.:]+/)*[-a-z0-9]+`
+
+	patCJKPunct   = `（）「」『』【】〈〉《》、。，：`
+	patCodeBefore = `^|[\s\p{Zs}` + patNoSpaceScripts + patCJKPunct + `]`
+	patCodeAfter  = `$|[.,\s\p{Zs}` + patNoSpaceScripts + patCJKPunct + `]`
+)
+
+var predefCodePatterns = []string{
+	"interface",
+	"struct",
+	`(?:` + patPkgPath + `\.)?` + patExportedIdent + `\.` + patExportedIdent,
+	patPkgPath + `\.` + `(?:` + patExportedIdent + `\.)?` + patExportedIdent,
+	`\(\*` + `(?:` + patPkgPath + `\.)?` + patExportedIdent + `\)\.` + patExportedIdent,
+}
+
+// mkCodeRegexp returns a regexp matching Go expressions in doc text,
+// which are rendered as code spans. idents are matched too
+// in addition to the qualified identifiers.
+func mkCodeRegexp(idents []string) *regexp.Regexp {
+	patterns := append([]string{}, predefCodePatterns...)
+	for _, ident := range idents {
+		patterns = append(patterns, regexp.QuoteMeta(ident))
+	}
+	return regexp.MustCompile(
+		`(` + patCodeBefore + `)((?:` + strings.Join(patterns, "|") + `)` +
+			`(?:\{.*?\}|\[.*?\]|\(.*?\))?)(` + patCodeAfter + `)`,
+	)
+}
+
+var rxCode = mkCodeRegexp(nil)
+
+var rxListItem = regexp.MustCompile(`^([ \t]*)([-*+•]|[0-9]+[.)])[ \t]+(.*)$`)
+
+// listItemSource is a list item line in the doc comment source.
+type listItemSource struct {
+	indent int
+	text   string
+}
+
+// listItemSources returns the lines of docString which look like list items.
+// go/doc/comment does not retain the nesting of list items, which is
+// recovered from them.
+func listItemSources(docString string) []listItemSource {
+	var items []listItemSource
+	for _, line := range strings.Split(docString, "\n") {
+		m := rxListItem.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		items = append(items, listItemSource{
+			indent: len(strings.Replace(m[1], "\t", "    ", -1)),
+			text:   strings.TrimSpace(m[3]),
+		})
+	}
+	return items
+}
+
+// markdownOptions controls the rendering of doc comments.
+type markdownOptions struct {
+	// HeadingLevel is the level of headings in doc comments.
+	HeadingLevel int
+	// Fence renders code blocks as fenced code blocks instead of indented ones.
+	Fence bool
+	// FenceLang is the language tag of fenced code blocks.
+	FenceLang string
+	// Reflow re-flows paragraphs to Wrap columns, or joins each of them
+	// into a line if Wrap is 0. Otherwise line breaks are kept as is.
+	Reflow bool
+	Wrap   int
+	// LinkIdents links identifiers of the package and its imports
+	// to their documentation.
+	LinkIdents bool
+}
+
+// markdownRenderer renders the blocks of a parsed doc comment as Markdown.
+type markdownRenderer struct {
+	markdownOptions
+	sources    []listItemSource
+	importPath string
+	rxCode     *regexp.Regexp
+	parser     *comment.Parser
+	// indent is the width of the indentation of the current list item.
+	indent int
+}
+
+// docLinkURL returns the URL of the documentation on pkg.go.dev
+// which a doc link refers to.
+func (m *markdownRenderer) docLinkURL(l *comment.DocLink) string {
+	if l.ImportPath == "" {
+		// a symbol in the package itself
+		l2 := *l
+		l2.ImportPath = m.importPath
+		l = &l2
+	}
+	return l.DefaultURL("https://pkg.go.dev")
+}
+
+// text renders inline text.
+func (m *markdownRenderer) text(out *bytes.Buffer, text []comment.Text) {
+	for _, t := range text {
+		switch t := t.(type) {
+		case comment.Plain:
+			m.plain(out, string(t))
+		case comment.Italic:
+			out.WriteString("*" + escapeMarkdown(string(t)) + "*")
+		case *comment.Link:
+			if t.Auto {
+				// the parser has excluded trailing punctuations from the URL
+				out.WriteString("[" + escapeMarkdown(t.URL) + "](" + t.URL + ")")
+			} else {
+				out.WriteString("[")
+				m.text(out, t.Text)
+				out.WriteString("](" + t.URL + ")")
+			}
+		case *comment.DocLink:
+			// the text of a doc link is always a Go identifier
+			out.WriteString("[`" + plainText(t.Text) + "`](" + m.docLinkURL(t) + ")")
+		}
+	}
+}
+
+// plain renders plain text, marking Go expressions as code spans.
+// Code spans already in the text are kept as is.
+func (m *markdownRenderer) plain(out *bytes.Buffer, s string) {
+	for s != "" {
+		i := strings.IndexByte(s, '`')
+		if i == -1 {
+			m.prose(out, s)
+			return
+		}
+		j := strings.IndexByte(s[i+1:], '`')
+		if j == -1 {
+			m.prose(out, s)
+			return
+		}
+		end := i + 1 + j + 1
+		m.prose(out, s[:i])
+		out.WriteString(s[i:end])
+		s = s[end:]
+	}
+}
+
+// prose renders text outside code spans.
+func (m *markdownRenderer) prose(out *bytes.Buffer, s string) {
+	last := 0
+	for _, loc := range m.rxCode.FindAllStringSubmatchIndex(s, -1) {
+		out.WriteString(escapeMarkdown(s[last:loc[4]]))
+		code := s[loc[4]:loc[5]]
+		var link *comment.DocLink
+		if m.LinkIdents {
+			link = m.identLink(code)
+		}
+		if link != nil {
+			out.WriteString("[`" + code + "`](" + m.docLinkURL(link) + ")")
+		} else {
+			out.WriteString("`" + code + "`")
+		}
+		last = loc[5]
+	}
+	out.WriteString(escapeMarkdown(s[last:]))
+}
+
+// identLink resolves the identifier in code, such as "Client.Do",
+// "(*http.Client).Do" or "http.Client{}", to a doc link. It returns nil
+// if the identifier is not in the package or its imports.
+func (m *markdownRenderer) identLink(code string) *comment.DocLink {
+	if m.parser == nil {
+		return nil
+	}
+
+	code = strings.Replace(strings.TrimPrefix(code, "(*"), ").", ".", 1)
+	if i := strings.IndexAny(code, "{[("); i != -1 {
+		code = code[:i]
+	}
+
+	var importPath string
+	if i := strings.LastIndex(code, "/"); i != -1 {
+		j := strings.Index(code[i:], ".")
+		if j == -1 {
+			return nil
+		}
+		importPath, code = code[:i+j], code[i+j+1:]
+	}
+
+	parts := strings.Split(code, ".")
+	if importPath == "" && len(parts) > 1 {
+		if len(parts) == 2 && m.parser.LookupSym(parts[0], parts[1]) {
+			return &comment.DocLink{Recv: parts[0], Name: parts[1]}
+		}
+		path, ok := m.parser.LookupPackage(parts[0])
+		if !ok {
+			return nil
+		}
+		importPath, parts = path, parts[1:]
+	}
+
+	switch {
+	case len(parts) == 1 && (importPath != "" || m.parser.LookupSym("", parts[0])):
+		return &comment.DocLink{ImportPath: importPath, Name: parts[0]}
+	case len(parts) == 2 && importPath != "":
+		return &comment.DocLink{ImportPath: importPath, Recv: parts[0], Name: parts[1]}
+	}
+	return nil
+}
+
+// escapeMarkdown escapes the characters in text which would be
+// interpreted as Markdown syntax.
+func escapeMarkdown(text string) string {
+	var b strings.Builder
+
+	rs := []rune(text)
+	lineStart := true
+	for i, r := range rs {
+		prev, next := ' ', ' '
+		if i > 0 {
+			prev = rs[i-1]
+		}
+		if i+1 < len(rs) {
+			next = rs[i+1]
+		}
+
+		switch r {
+		case '`', '|':
+			b.WriteRune('\\')
+		case '*', '_':
+			// underscores within words and asterisks between spaces
+			// do not start emphasis
+			if r == '_' && isWordRune(prev) && isWordRune(next) {
+				break
+			}
+			if unicode.IsSpace(prev) && unicode.IsSpace(next) {
+				break
+			}
+			b.WriteRune('\\')
+		case '<':
+			// may start an HTML tag or an autolink
+			if unicode.IsLetter(next) || strings.ContainsRune("/!?", next) {
+				b.WriteRune('\\')
+			}
+		case '>':
+			// may start a block quote
+			if lineStart {
+				b.WriteRune('\\')
+			}
+		case '\\':
+			if next <= unicode.MaxASCII && (unicode.IsPunct(next) || unicode.IsSymbol(next)) {
+				b.WriteRune('\\')
+			}
+		}
+		b.WriteRune(r)
+
+		if r == '\n' {
+			lineStart = true
+		} else if !unicode.IsSpace(r) {
+			lineStart = false
+		}
+	}
+
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// plainText returns text without markup, to find the source of list items.
+func plainText(text []comment.Text) string {
+	var b strings.Builder
+	for _, t := range text {
+		switch t := t.(type) {
+		case comment.Plain:
+			b.WriteString(string(t))
+		case comment.Italic:
+			b.WriteString(string(t))
+		case *comment.Link:
+			b.WriteString(plainText(t.Text))
+		case *comment.DocLink:
+			b.WriteString(plainText(t.Text))
+		}
+	}
+	return b.String()
+}
+
+func (m *markdownRenderer) block(out *bytes.Buffer, b comment.Block) {
+	switch b := b.(type) {
+	case *comment.Paragraph:
+		if !m.Reflow {
+			m.text(out, b.Text)
+		} else {
+			var p bytes.Buffer
+			m.text(&p, b.Text)
+			width := m.Wrap
+			if width > 0 {
+				width = maxInt(width-m.indent, 1)
+			}
+			out.WriteString(reflow(p.String(), width))
+		}
+		out.WriteString("\n")
+
+	case *comment.Heading:
+		out.WriteString(strings.Repeat("#", m.HeadingLevel) + " ")
+		m.text(out, b.Text)
+		out.WriteString("\n")
+
+	case *comment.Code:
+		if m.Fence {
+			fence := codeFence(b.Text)
+			lang := m.FenceLang
+			if lang == "auto" {
+				lang = detectCodeLang(b.Text)
+			}
+			out.WriteString(fence + lang + "\n" + b.Text + fence + "\n")
+			break
+		}
+
+		lines := strings.SplitAfter(b.Text, "\n")
+		for i, line := range lines {
+			if i == len(lines)-1 && line == "" {
+				// nop
+			} else {
+				out.WriteString("    ")
+			}
+			out.WriteString(line)
+		}
+		out.WriteString("\n")
+
+	case *comment.List:
+		m.list(out, b)
+	}
+}
+
+// list renders a list, nesting items by the indentation of
+// the corresponding source lines.
+func (m *markdownRenderer) list(out *bytes.Buffer, list *comment.List) {
+	type level struct {
+		srcIndent int
+		indent    int
+		width     int
+	}
+
+	var stack []level
+
+	loose := list.BlankBetween()
+	for i, item := range list.Items {
+		srcIndent := 0
+		if len(item.Content) > 0 {
+			if p, ok := item.Content[0].(*comment.Paragraph); ok {
+				first := strings.SplitN(plainText(p.Text), "\n", 2)[0]
+				for j, s := range m.sources {
+					if s.text == first {
+						srcIndent = s.indent
+						m.sources = m.sources[j+1:]
+						break
+					}
+				}
+			}
+		}
+
+		marker := "-"
+		if item.Number != "" {
+			marker = item.Number + "."
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].srcIndent > srcIndent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 || stack[len(stack)-1].srcIndent < srcIndent {
+			indent := 0
+			if len(stack) > 0 {
+				indent = stack[len(stack)-1].indent + stack[len(stack)-1].width
+			}
+			stack = append(stack, level{srcIndent: srcIndent, indent: indent})
+		}
+		top := &stack[len(stack)-1]
+		top.width = len(marker) + 1
+
+		if i > 0 && loose {
+			out.WriteString("\n")
+		}
+
+		prefix := strings.Repeat(" ", top.indent)
+		cont := prefix + strings.Repeat(" ", top.width)
+		for j, c := range item.Content {
+			var b bytes.Buffer
+			m.indent = len(cont)
+			m.block(&b, c)
+			m.indent = 0
+			s := strings.TrimSuffix(b.String(), "\n")
+			if j == 0 {
+				out.WriteString(prefix + marker + " ")
+			} else {
+				out.WriteString("\n" + cont)
+			}
+			out.WriteString(strings.Replace(s, "\n", "\n"+cont, -1))
+			out.WriteString("\n")
+		}
+	}
+}
+
+// codeFence returns a fence of backticks longer than any run of
+// backticks in code.
+func codeFence(code string) string {
+	n := 3
+	run := 0
+	for _, c := range code {
+		if c != '`' {
+			run = 0
+			continue
+		}
+		run++
+		if run >= n {
+			n = run + 1
+		}
+	}
+	return strings.Repeat("`", n)
+}
+
+// shellCommands are the commands which code blocks in shell usually start with.
+var shellCommands = map[string]bool{
+	"go": true, "git": true, "curl": true, "wget": true, "make": true, "docker": true,
+	"cd": true, "export": true, "echo": true, "sudo": true, "brew": true, "apt-get": true,
+}
+
+// detectCodeLang guesses the language of code as one of "go", "sh" and
+// "json", or returns "" for plain text.
+func detectCodeLang(code string) string {
+	trimmed := strings.TrimSpace(code)
+	if trimmed == "" {
+		return ""
+	}
+
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+
+	if first := strings.Fields(trimmed); first[0] == "$" || shellCommands[first[0]] {
+		return "sh"
+	}
+
+	// a bare identifier or a sentence may be valid Go, but is unlikely code
+	if strings.ContainsAny(trimmed, "(){}=") {
+		for _, src := range []string{
+			code,
+			"package p\n" + code,
+			"package p\nfunc _() {\n" + code + "\n}",
+		} {
+			if _, err := parser.ParseFile(token.NewFileSet(), "", src, 0); err == nil {
+				return "go"
+			}
+		}
+	}
+
+	return ""
+}
+
+// packageIdents returns the exported top-level identifiers of pkg.
+func packageIdents(pkg *doc.Package) []string {
+	var idents []string
+	addValues := func(values []*doc.Value) {
+		for _, v := range values {
+			idents = append(idents, v.Names...)
+		}
+	}
+	addFuncs := func(funcs []*doc.Func) {
+		for _, f := range funcs {
+			idents = append(idents, f.Name)
+		}
+	}
+
+	addValues(pkg.Consts)
+	addValues(pkg.Vars)
+	addFuncs(pkg.Funcs)
+	for _, t := range pkg.Types {
+		idents = append(idents, t.Name)
+		addValues(t.Consts)
+		addValues(t.Vars)
+		addFuncs(t.Funcs)
+	}
+
+	exported := idents[:0]
+	for _, ident := range idents {
+		if token.IsExported(ident) {
+			exported = append(exported, ident)
+		}
+	}
+	return exported
+}
+
+// renderMarkdown renders docString as Markdown. Doc links such as [Name]
+// and [pkg.Name] are resolved against pkg, which may be nil.
+// Headings, either "# Heading" or implicit ones, are rendered at
+// opts.HeadingLevel so that they fit in the README structure.
+func renderMarkdown(docString string, pkg *doc.Package, opts markdownOptions) string {
+	if opts.HeadingLevel > 6 {
+		opts.HeadingLevel = 6
+	}
+
+	m := &markdownRenderer{
+		markdownOptions: opts,
+		sources:         listItemSources(docString),
+		rxCode:          rxCode,
+	}
+
+	p := &comment.Parser{}
+	if pkg != nil {
+		p = pkg.Parser()
+		m.importPath = pkg.ImportPath
+		m.parser = p
+		if opts.LinkIdents {
+			m.rxCode = mkCodeRegexp(packageIdents(pkg))
+		}
+	}
+	d := p.Parse(docString)
+
+	blocks := make([]string, 0, len(d.Content))
+	for _, b := range d.Content {
+		var out bytes.Buffer
+		m.block(&out, b)
+		blocks = append(blocks, out.String())
+	}
+
+	return strings.Join(blocks, "\n")
+}
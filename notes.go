@@ -0,0 +1,86 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ReleaseNotes are the changes since the latest release collected from
+// the commit messages following Conventional Commits.
+type ReleaseNotes struct {
+	// Since is the git revision the changes are since, or "" for all
+	// the commits.
+	Since string
+	// Breaking, Features and Fixes are the descriptions of the commits
+	// of breaking changes, "feat" and "fix" respectively, oldest first.
+	Breaking []string
+	Features []string
+	Fixes    []string
+}
+
+// Empty reports whether there are no notable changes.
+func (n ReleaseNotes) Empty() bool {
+	return len(n.Breaking) == 0 && len(n.Features) == 0 && len(n.Fixes) == 0
+}
+
+// rxConventionalCommit matches the subject of a commit message following
+// Conventional Commits, e.g. "feat(parser)!: support arrays".
+var rxConventionalCommit = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?: *(.+)$`)
+
+// parseConventionalCommit parses the commit message msg and returns
+// its type, the description prefixed by the scope if any, and whether
+// it is a breaking change. ok is false if msg does not follow the format.
+func parseConventionalCommit(msg string) (typ, desc string, breaking, ok bool) {
+	lines := strings.SplitN(strings.TrimSpace(msg), "\n", 2)
+	m := rxConventionalCommit.FindStringSubmatch(lines[0])
+	if m == nil {
+		return "", "", false, false
+	}
+
+	typ, desc = strings.ToLower(m[1]), strings.TrimSpace(m[4])
+	if m[2] != "" {
+		desc = "**" + m[2] + ":** " + desc
+	}
+	breaking = m[3] == "!"
+	if len(lines) == 2 {
+		for _, line := range strings.Split(lines[1], "\n") {
+			if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+				breaking = true
+			}
+		}
+	}
+	return typ, desc, breaking, true
+}
+
+// releaseNotes collects the changes to the package in dir since the git
+// revision since, or in all the history if since is "".
+func releaseNotes(dir, since string) (*ReleaseNotes, error) {
+	args := []string{"log", "--reverse", "--no-merges", "--format=%B%x1e"}
+	if since != "" {
+		args = append(args, since+"..HEAD")
+	} else {
+		args = append(args, "HEAD")
+	}
+	out, err := gitOutput(dir, append(args, "--", ".")...)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := &ReleaseNotes{Since: since}
+	for _, msg := range strings.Split(out, "\x1e") {
+		typ, desc, breaking, ok := parseConventionalCommit(msg)
+		if !ok {
+			continue
+		}
+		switch {
+		case breaking:
+			notes.Breaking = append(notes.Breaking, desc)
+		case typ == "feat":
+			notes.Features = append(notes.Features, desc)
+		case typ == "fix":
+			notes.Fixes = append(notes.Fixes, desc)
+		}
+	}
+
+	return notes, nil
+}
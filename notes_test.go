@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		msg      string
+		typ      string
+		desc     string
+		breaking bool
+		ok       bool
+	}{
+		{"feat: add the index section", "feat", "add the index section", false, true},
+		{"fix(badge): escape slashes\n\nFixes #12.\n", "fix", "**badge:** escape slashes", false, true},
+		{"feat!: drop Go 1.16", "feat", "drop Go 1.16", true, true},
+		{"refactor: rename Config\n\nBREAKING CHANGE: Config is now Options.", "refactor", "rename Config", true, true},
+		{"Merge pull request #1 from motemen/foo", "", "", false, false},
+		{"Update README.md", "", "", false, false},
+	}
+
+	for _, test := range tests {
+		typ, desc, breaking, ok := parseConventionalCommit(test.msg)
+		if typ != test.typ || desc != test.desc || breaking != test.breaking || ok != test.ok {
+			t.Errorf("parseConventionalCommit(%q) = %q, %q, %v, %v, expected %q, %q, %v, %v",
+				test.msg, typ, desc, breaking, ok, test.typ, test.desc, test.breaking, test.ok)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// codeOwnersFiles are the locations of CODEOWNERS relative to the repository
+// root, in the order of precedence.
+var codeOwnersFiles = []string{
+	filepath.Join(".github", "CODEOWNERS"),
+	"CODEOWNERS",
+	filepath.Join("docs", "CODEOWNERS"),
+	filepath.Join(".gitlab", "CODEOWNERS"),
+}
+
+// codeOwners returns the owners of the package directory pkgDir, relative to
+// the repository root, by the CODEOWNERS file of the repository containing dir.
+// Users are linked to their pages on repo, if known. Teams are omitted.
+func codeOwners(dir, pkgDir string, repo *Repository) []Person {
+	root := repoRoot(dir)
+	if root == "" {
+		return nil
+	}
+
+	var content string
+	for _, name := range codeOwnersFiles {
+		if b, err := ioutil.ReadFile(filepath.Join(root, name)); err == nil {
+			content = string(b)
+			break
+		}
+	}
+
+	var owners []string
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !codeOwnersPatternMatches(fields[0], pkgDir) {
+			continue
+		}
+		// the last matching pattern takes precedence
+		owners = fields[1:]
+	}
+
+	var people []Person
+	for _, owner := range owners {
+		switch {
+		case strings.HasPrefix(owner, "@") && !strings.Contains(owner, "/"):
+			p := Person{Name: owner[1:]}
+			if repo != nil {
+				p.URL = repo.Forge().AuthorFromPath(Repository{Kind: repo.Kind, Host: repo.Host, Path: p.Name}).URL
+			}
+			people = append(people, p)
+		case strings.Contains(owner, "@") && !strings.HasPrefix(owner, "@"):
+			people = append(people, Person{Name: owner, Email: owner})
+		}
+	}
+
+	return people
+}
+
+// codeOwnersPatternMatches reports whether the CODEOWNERS pattern matches
+// the files directly in the directory pkgDir. As in .gitignore, patterns
+// match directories with their contents at any depth unless they contain
+// a slash other than the trailing one, which anchors them at the root.
+// Patterns of files other than "*" are not supported.
+func codeOwnersPatternMatches(pattern, pkgDir string) bool {
+	switch pattern {
+	case "*", "/", "/*", "**", "/**":
+		return true
+	}
+
+	pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "**"), "*")
+	dir := strings.Trim(pattern, "/")
+	if dir == "" {
+		return false
+	}
+
+	patterns := strings.Split(dir, "/")
+	var dirs []string
+	if pkgDir != "" {
+		dirs = strings.Split(pkgDir, "/")
+	}
+
+	// matchesAt reports whether the pattern matches the ancestor of pkgDir
+	// (or itself) starting at dirs[i].
+	matchesAt := func(i int) bool {
+		if i+len(patterns) > len(dirs) {
+			return false
+		}
+		for j, p := range patterns {
+			if ok, _ := path.Match(p, dirs[i+j]); !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	if strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		return matchesAt(0)
+	}
+	for i := range dirs {
+		if matchesAt(i) {
+			return true
+		}
+	}
+	return false
+}
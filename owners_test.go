@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCodeOwners(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".github"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	codeowners := `# default owners
+*       @motemen @org/reviewers
+/sub/   @alice bob@example.com # sub package
+docs/   @carol
+/cmd/*-server  @dave
+internal   @erin
+`
+	if err := ioutil.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte(codeowners), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &Repository{Kind: "github", Host: "github.com", Path: "motemen/goreadme"}
+
+	tests := []struct {
+		pkgDir   string
+		expected []Person
+	}{
+		{"", []Person{{Name: "motemen", URL: "https://github.com/motemen"}}},
+		{"sub", []Person{{Name: "alice", URL: "https://github.com/alice"}, {Name: "bob@example.com", Email: "bob@example.com"}}},
+		{"sub/docs", []Person{{Name: "carol", URL: "https://github.com/carol"}}},
+		{"other/sub", []Person{{Name: "motemen", URL: "https://github.com/motemen"}}},
+		{"cmd/api-server", []Person{{Name: "dave", URL: "https://github.com/dave"}}},
+		{"cmd/api", []Person{{Name: "motemen", URL: "https://github.com/motemen"}}},
+		{"sub/internal/util", []Person{{Name: "erin", URL: "https://github.com/erin"}}},
+	}
+
+	for _, test := range tests {
+		if got := codeOwners(root, test.pkgDir, repo); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("codeOwners(%q) = %+v, expected %+v", test.pkgDir, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"go/build"
+	"strings"
+)
+
+// PlatformMatrix is the table of the platforms the package supports.
+type PlatformMatrix struct {
+	// Arches are the GOARCH values of the columns.
+	Arches []string
+	// Rows are the GOOS values with whether the package supports them on
+	// each of Arches.
+	Rows []PlatformRow
+}
+
+// PlatformRow is a row of PlatformMatrix.
+type PlatformRow struct {
+	OS        string
+	Supported []bool
+}
+
+// platformOSes and platformArches are the GOOS and GOARCH values checked
+// for the supported platforms unless configured by GoReleaser.
+var (
+	platformOSes   = []string{"linux", "darwin", "windows", "freebsd"}
+	platformArches = []string{"amd64", "arm64", "386", "arm"}
+)
+
+// ports are the GOOS/GOARCH pairs supported by Go, by "go tool dist list".
+var ports = func() map[string]bool {
+	m := map[string]bool{}
+	for _, p := range strings.Fields(`
+		aix/ppc64 android/386 android/amd64 android/arm android/arm64 darwin/amd64 darwin/arm64
+		dragonfly/amd64 freebsd/386 freebsd/amd64 freebsd/arm freebsd/arm64 illumos/amd64
+		ios/amd64 ios/arm64 js/wasm linux/386 linux/amd64 linux/arm linux/arm64 linux/loong64
+		linux/mips linux/mips64 linux/mips64le linux/mipsle linux/ppc64 linux/ppc64le
+		linux/riscv64 linux/s390x netbsd/386 netbsd/amd64 netbsd/arm netbsd/arm64
+		openbsd/386 openbsd/amd64 openbsd/arm openbsd/arm64 openbsd/ppc64 openbsd/riscv64
+		plan9/386 plan9/amd64 plan9/arm solaris/amd64 wasip1/wasm
+		windows/386 windows/amd64 windows/arm windows/arm64
+	`) {
+		m[p] = true
+	}
+	return m
+}()
+
+// detectPlatforms returns the platforms the package in dir can be built for
+// by the build constraints, or the ones released by GoReleaser configured by
+// gr if not nil. It returns nil if the package supports all the platforms
+// checked without GoReleaser.
+func detectPlatforms(dir string, gr *goreleaserConfig) *PlatformMatrix {
+	oses, arches := platformOSes, platformArches
+	ignored := map[string]bool{}
+	released := gr != nil && len(gr.platforms()) > 0
+	if released {
+		oses, arches, ignored = nil, nil, map[string]bool{}
+		seen := map[string]bool{}
+		for _, p := range gr.platforms() {
+			if !seen[p.OS] {
+				seen[p.OS] = true
+				oses = append(oses, p.OS)
+			}
+			if !seen["arch:"+p.Arch] {
+				seen["arch:"+p.Arch] = true
+				arches = append(arches, p.Arch)
+			}
+		}
+		for _, goos := range oses {
+			for _, goarch := range arches {
+				ignored[goos+"/"+goarch] = true
+			}
+		}
+		for _, p := range gr.platforms() {
+			delete(ignored, p.OS+"/"+p.Arch)
+		}
+	}
+
+	m := &PlatformMatrix{Arches: arches}
+	all := true
+	for _, goos := range oses {
+		row := PlatformRow{OS: goos}
+		for _, goarch := range arches {
+			if !ports[goos+"/"+goarch] {
+				row.Supported = append(row.Supported, false)
+				continue
+			}
+			ctx := build.Default
+			ctx.GOOS, ctx.GOARCH = goos, goarch
+			_, err := ctx.ImportDir(dir, 0)
+			ok := err == nil && !ignored[goos+"/"+goarch]
+			row.Supported = append(row.Supported, ok)
+			all = all && ok
+		}
+		m.Rows = append(m.Rows, row)
+	}
+
+	if all && !released {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectPlatforms(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("foo.go", "package foo\n")
+	if m := detectPlatforms(dir, loadGoReleaserConfig(dir)); m != nil {
+		t.Errorf("detectPlatforms() = %+v, expected nil", m)
+	}
+
+	write("foo.go", "//go:build linux || darwin\n\npackage foo\n")
+	expected := &PlatformMatrix{
+		Arches: []string{"amd64", "arm64", "386", "arm"},
+		Rows: []PlatformRow{
+			{"linux", []bool{true, true, true, true}},
+			{"darwin", []bool{true, true, false, false}},
+			{"windows", []bool{false, false, false, false}},
+			{"freebsd", []bool{false, false, false, false}},
+		},
+	}
+	if m := detectPlatforms(dir, loadGoReleaserConfig(dir)); !reflect.DeepEqual(m, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", m, expected)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write(".goreleaser.yaml", `builds:
+  - goos: [linux, windows]
+    goarch: [amd64, arm64]
+    ignore:
+      - goos: linux
+        goarch: arm64
+`)
+	expected = &PlatformMatrix{
+		Arches: []string{"amd64", "arm64"},
+		Rows: []PlatformRow{
+			{"linux", []bool{true, false}},
+			{"windows", []bool{false, false}},
+		},
+	}
+	if m := detectPlatforms(dir, loadGoReleaserConfig(dir)); !reflect.DeepEqual(m, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", m, expected)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/doc"
+	"go/format"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+var (
+	// playgroundShareURL is the endpoint to share programs on the Go Playground.
+	playgroundShareURL = "https://play.golang.org/share"
+	// playgroundLinkPrefix is prepended to the IDs of shared programs.
+	playgroundLinkPrefix = "https://go.dev/play/p/"
+)
+
+// sharePlayground uploads the Go program src to the Go Playground and
+// returns the link to it.
+func sharePlayground(src []byte) (string, error) {
+	resp, err := http.Post(playgroundShareURL, "text/plain; charset=utf-8", bytes.NewReader(src))
+	if err != nil {
+		return "", withStatus(exitVCSOrNetwork, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", withStatus(exitVCSOrNetwork, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", withStatus(exitVCSOrNetwork, fmt.Errorf("sharing on the Go Playground: %s: %s", resp.Status, strings.TrimSpace(string(body))))
+	}
+
+	return playgroundLinkPrefix + strings.TrimSpace(string(body)), nil
+}
+
+// sharePlayableExamples shares the playable examples of r on the Go Playground
+// and records the links to them.
+func (r *Readme) sharePlayableExamples() error {
+	r.playLinks = map[*doc.Example]string{}
+
+	for _, ex := range r.Examples {
+		if ex.Play == nil {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := format.Node(&buf, r.fset, ex.Play); err != nil {
+			return err
+		}
+
+		link, err := sharePlayground(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		r.playLinks[ex] = link
+	}
+
+	return nil
+}
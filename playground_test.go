@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSharePlayground(t *testing.T) {
+	var received string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		received = string(b)
+		w.Write([]byte("abc123"))
+	}))
+	defer ts.Close()
+
+	defer func(url string) { playgroundShareURL = url }(playgroundShareURL)
+	playgroundShareURL = ts.URL
+
+	src := "package main\n\nfunc main() {}\n"
+	link, err := sharePlayground([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received != src {
+		t.Errorf("uploaded %q, expected %q", received, src)
+	}
+	if expected := "https://go.dev/play/p/abc123"; link != expected {
+		t.Errorf("sharePlayground = %q, expected %q", link, expected)
+	}
+}
@@ -0,0 +1,915 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/motemen/go-gitconfig"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+type Readme struct {
+	fset     *token.FileSet
+	markdown markdownOptions
+	Pkg      *doc.Package
+	Examples []*doc.Example
+	Exports  []string
+	Badges   []string
+	// Author is the author of the package, loaded from git config unless
+	// configured.
+	Author Person
+	// Authors are the authors listed in the AUTHORS file. If empty, Author
+	// is the only one.
+	Authors []Person
+	// Maintainers are the maintainers of the package from the configuration,
+	// the MAINTAINERS file or CODEOWNERS.
+	Maintainers []Person
+	// Contributors are the authors of the commits to the package, which
+	// are collected only if the contributors section is generated.
+	// See loadContributors.
+	Contributors []Contributor
+	// DocSite is the URL of the documentation site, e.g. a self-hosted pkgsite.
+	// The default is https://pkg.go.dev.
+	DocSite string
+	// ExampleDeps are the packages imported by examples but not required
+	// by the module's go.mod.
+	ExampleDeps []string
+	// Since is the git revision which APIChanges is compared against.
+	Since string
+	// APIChanges is the difference of the API since the revision Since,
+	// which is set only when requested.
+	APIChanges *APIDiff
+	// Sections are the names of the sections to be generated, in order.
+	Sections []string
+	// Snippets are the named texts from the configuration.
+	Snippets map[string]string
+	// Unexported is true if unexported symbols are documented.
+	Unexported bool
+	// Collapse are the names of the sections to be collapsed in <details> blocks.
+	Collapse []string
+	// Benchmarks are the benchmark functions in the test files.
+	Benchmarks []TestFunc
+	// FuzzTargets are the fuzz targets in the test files.
+	FuzzTargets []TestFunc
+	// Coverage is the test coverage of the package, e.g. "85.3%",
+	// which is measured only when requested.
+	Coverage string
+	// CollapseOutput is true if the outputs of examples are collapsed.
+	CollapseOutput bool
+	// FieldTables is true if struct types are rendered as tables of their
+	// fields instead of code blocks.
+	FieldTables bool
+	// Repository is the repository of the package, or nil if its host is
+	// not known.
+	Repository *Repository
+	// Branch is the branch of the repository the badges and source links
+	// refer to.
+	Branch string
+	// License is the license of the package, or nil if no license file is found.
+	License *License
+	// ContributingPath is the path of the contributing guidelines relative
+	// to the package directory, e.g. ".github/CONTRIBUTING.md".
+	ContributingPath string
+	// CodeOfConductPath is the path of the code of conduct relative to
+	// the package directory.
+	CodeOfConductPath string
+	// ChangelogURL is the path of the changelog relative to the package
+	// directory, or the URL of the releases page of the repository if there
+	// is no changelog but release tags.
+	ChangelogURL string
+	// WhatsNew are the changes since the latest tag, which are collected
+	// only if the whats-new section is generated.
+	WhatsNew *ReleaseNotes
+	// GoVersion is the minimum Go version by the go directive of go.mod,
+	// e.g. "1.21".
+	GoVersion string
+	// Toolchain is the Go toolchain by the toolchain directive of go.mod,
+	// e.g. "go1.22.3".
+	Toolchain string
+	// Platforms are the platforms the package supports, or nil if it
+	// supports all the common ones.
+	Platforms *PlatformMatrix
+	// Homebrew is the Homebrew formula of the command, e.g.
+	// "motemen/tap/goreadme", and HomebrewCask is whether it is a cask.
+	Homebrew     string
+	HomebrewCask bool
+	// Downloads are the prebuilt binaries of Version released by GoReleaser.
+	Downloads []Download
+	// ReleasesURL is the URL of the releases page of the repository.
+	ReleasesURL string
+	// Version is the latest version tag reachable from HEAD, e.g. "v1.2.3".
+	Version string
+	// Releases are the latest annotated tags shown in the changelog section
+	// if configured.
+	Releases []Release
+	// Sponsors are the sponsorship links in .github/FUNDING.yml.
+	Sponsors []Sponsor
+	// Flags are the command line flags of the command.
+	Flags []Flag
+	// Help is the output of the command run with -h, if requested.
+	Help string
+	// Binaries are the commands in the cmd directory of the package.
+	Binaries []Binary
+	// Commands are the cobra or urfave/cli commands of the command, the
+	// root first.
+	Commands []Command
+	// DockerImage is the Docker image to run the package with, e.g.
+	// "ghcr.io/motemen/goreadme".
+	DockerImage string
+	// Env are the environment variables the package reads.
+	Env []EnvVar
+	// ExitCodes are the statuses the command exits with.
+	ExitCodes []ExitCode
+	// ConfigFields are the fields of the configuration struct of the package.
+	ConfigFields []ConfigField
+
+	// playLinks are the links to the examples shared on the Go Playground.
+	playLinks map[*doc.Example]string
+	// exampleBody is true if only the bodies of examples are rendered,
+	// even if they are playable as whole programs.
+	exampleBody bool
+	// anchors are the anchors of the headings in the rendered README,
+	// keyed by the names of the sections and the texts of the headings.
+	// See execute.
+	anchors map[[2]string]string
+	// markSections is true while the anchors are collected, to mark the
+	// sections in the output.
+	markSections bool
+	// slugs numbers the anchors generated by the slug template function.
+	slugs *slugger
+	// pkgDir is the directory of the package relative to the repository
+	// root in slash-separated form, or "" for the root.
+	pkgDir string
+}
+
+// AllSections are the names of the sections in the default template,
+// in the default order.
+var AllSections = []string{"badges", "doc", "installation", "binaries", "requirements", "platforms", "usage", "commands", "configuration", "environment", "exit-codes", "docker", "examples", "benchmarks", "fuzz", "coverage", "index", "api", "interfaces", "types", "values", "api-changes", "todo", "bugs", "whats-new", "changelog", "contributing", "sponsors", "license", "author", "contributors"}
+
+// DefaultSections are the sections generated unless configured otherwise.
+var DefaultSections = []string{"badges", "doc", "installation", "binaries", "requirements", "platforms", "usage", "commands", "configuration", "environment", "exit-codes", "docker", "examples", "api-changes", "todo", "bugs", "changelog", "contributing", "sponsors", "license", "author"}
+
+// SourceURL returns the URL of the file at path, relative to the package
+// directory, in the repository on Branch. It returns "" if the repository
+// is not known.
+func (r Readme) SourceURL(path string) string {
+	if r.Repository == nil {
+		return ""
+	}
+	if r.pkgDir != "" {
+		path = r.pkgDir + "/" + path
+	}
+	return r.Repository.SourceURL(r.Branch, path)
+}
+
+// DocURL returns the URL of the package documentation on DocSite.
+func (r Readme) DocURL() string {
+	site := r.DocSite
+	if site == "" {
+		site = "https://pkg.go.dev"
+	}
+	return strings.TrimSuffix(site, "/") + "/" + r.Pkg.ImportPath
+}
+
+// HasSection reports whether the section name is to be generated.
+func (r Readme) HasSection(name string) bool {
+	for _, s := range r.Sections {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Collapsed reports whether the section name is to be collapsed.
+func (r Readme) Collapsed(name string) bool {
+	for _, s := range r.Collapse {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSections returns an error if names contain unknown section names.
+func checkSections(names []string) error {
+	for _, name := range names {
+		if !isKnownSection(name) {
+			return fmt.Errorf("unknown section %q (known sections: %s)", name, strings.Join(AllSections, ", "))
+		}
+	}
+	return nil
+}
+
+func isKnownSection(name string) bool {
+	for _, s := range AllSections {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSections resolves a list of section names. Names prefixed by "-"
+// are removed from DefaultSections; if there are only such names, the others
+// are generated in the default order. Otherwise only the listed sections
+// are generated, in the listed order.
+func parseSections(names []string) ([]string, error) {
+	var include []string
+	exclude := map[string]bool{}
+	for _, name := range names {
+		if strings.HasPrefix(name, "-") {
+			exclude[name[1:]] = true
+			name = name[1:]
+		} else {
+			include = append(include, name)
+		}
+		if err := checkSections([]string{name}); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(include) == 0 {
+		include = DefaultSections
+	}
+
+	sections := []string{}
+	for _, s := range include {
+		if !exclude[s] {
+			sections = append(sections, s)
+		}
+	}
+
+	return sections, nil
+}
+
+func (r Readme) IsCommand() bool {
+	return r.Pkg.Name == "main"
+}
+
+// InstallCommand returns the command to install the package. See
+// installCommand.
+func (r Readme) InstallCommand() string {
+	return installCommand(r.Pkg.ImportPath, r.IsCommand(), r.GoVersion, r.Version)
+}
+
+// installCommand returns the command to install the package of importPath,
+// "go install" for commands and "go get" for libraries. Commands of the
+// modules for Go before 1.16, which does not support "go install
+// PKG@VERSION", are installed by "go get -u".
+func installCommand(importPath string, command bool, goVersion, version string) string {
+	if !command {
+		return "go get " + importPath
+	}
+	if goVersion != "" && semver.Compare("v"+goVersion, "v1.16") < 0 {
+		return "go get -u " + importPath
+	}
+	if version == "" {
+		version = "latest"
+	}
+	return "go install " + importPath + "@" + version
+}
+
+func (r Readme) Name() string {
+	if r.IsCommand() {
+		// this package should be a command
+		parts := strings.Split(r.Pkg.ImportPath, "/")
+		return parts[len(parts)-1]
+	}
+
+	return r.Pkg.Name
+}
+
+// Funcs returns the exported functions of the package including
+// the ones associated with types, sorted by name.
+func (r Readme) Funcs() []*doc.Func {
+	funcs := append([]*doc.Func{}, r.Pkg.Funcs...)
+	for _, t := range r.Pkg.Types {
+		funcs = append(funcs, t.Funcs...)
+	}
+
+	sort.Slice(funcs, func(i, j int) bool {
+		return funcs[i].Name < funcs[j].Name
+	})
+
+	return funcs
+}
+
+// Consts returns the exported constant declarations of the package
+// including the ones associated with types.
+func (r Readme) Consts() []*doc.Value {
+	consts := append([]*doc.Value{}, r.Pkg.Consts...)
+	for _, t := range r.Pkg.Types {
+		consts = append(consts, t.Consts...)
+	}
+	return consts
+}
+
+// Vars returns the exported variable declarations of the package
+// including the ones associated with types.
+func (r Readme) Vars() []*doc.Value {
+	vars := append([]*doc.Value{}, r.Pkg.Vars...)
+	for _, t := range r.Pkg.Types {
+		vars = append(vars, t.Vars...)
+	}
+	return vars
+}
+
+// Interfaces returns the exported interface types of the package.
+func (r Readme) Interfaces() []*doc.Type {
+	var types []*doc.Type
+	for _, t := range r.Pkg.Types {
+		for _, spec := range t.Decl.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == t.Name {
+				if _, ok := ts.Type.(*ast.InterfaceType); ok {
+					types = append(types, t)
+				}
+			}
+		}
+	}
+	return types
+}
+
+// Person is an author or a maintainer of the package.
+type Person struct {
+	Name  string `gitconfig:"user.name" yaml:"name"`
+	Email string `gitconfig:"user.email" yaml:"email"`
+	// URL is the homepage of the author. Non-standard configuration.
+	URL string `gitconfig:"user.homepage" yaml:"url"`
+}
+
+// Homepage returns URL, for templates written before it was renamed.
+func (a Person) Homepage() string {
+	return a.URL
+}
+
+// importDir finds the package in dir, which may be relative to the current directory.
+func importDir(dir string) (*build.Package, error) {
+	if !filepath.IsAbs(dir) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(wd, dir)
+	}
+
+	bpkg, err := build.ImportDir(dir, build.FindOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	// outside GOPATH, the import path is derived from go.mod
+	if bpkg.ImportPath == "." || build.IsLocalImport(bpkg.ImportPath) {
+		if importPath := moduleImportPath(dir); importPath != "" {
+			bpkg.ImportPath = importPath
+		}
+	}
+
+	return bpkg, nil
+}
+
+// documentedPackage returns the package to document among pkgs parsed from
+// dir, which is the one the go command builds, rather than e.g. a "package
+// main" generator excluded by "//go:build ignore" next to a library. If it
+// is not among pkgs, e.g. parsed at another git revision, the first of the
+// others by name is returned. Test packages are never returned.
+func documentedPackage(dir string, pkgs map[string]*ast.Package) *ast.Package {
+	if bpkg, err := build.ImportDir(dir, 0); err == nil {
+		if pkg, ok := pkgs[bpkg.Name]; ok {
+			return pkg
+		}
+	}
+
+	var names []string
+	for name := range pkgs {
+		if !strings.HasSuffix(name, "_test") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return pkgs[names[0]]
+}
+
+// moduleImportPath returns the import path of the package in dir by
+// the module path in go.mod, or "" if it is not in a module.
+func moduleImportPath(dir string) string {
+	path := findGoMod(dir)
+	if path == "" {
+		return ""
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	modPath := modfile.ModulePath(b)
+	if modPath == "" {
+		return ""
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(path), dir)
+	if err != nil {
+		return ""
+	}
+	if rel == "." {
+		return modPath
+	}
+	return modPath + "/" + filepath.ToSlash(rel)
+}
+
+// goRequirements returns the minimum Go version and the toolchain required
+// by go.mod of the module containing dir, e.g. "1.21" and "go1.22.3".
+func goRequirements(dir string) (goVersion, toolchain string) {
+	path := findGoMod(dir)
+	if path == "" {
+		return "", ""
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", ""
+	}
+
+	// toolchain is parsed only in the strict mode
+	mf, err := modfile.Parse(path, b, nil)
+	if err != nil {
+		if mf, err = modfile.ParseLax(path, b, nil); err != nil {
+			return "", ""
+		}
+	}
+	if mf.Go != nil {
+		goVersion = mf.Go.Version
+	}
+	if mf.Toolchain != nil {
+		toolchain = mf.Toolchain.Name
+	}
+	return goVersion, toolchain
+}
+
+// loadOptions controls what loadReadme collects.
+type loadOptions struct {
+	// Mode controls the declarations documented.
+	Mode doc.Mode
+	// Examples selects the examples by their function names
+	// (e.g. "ExampleClient_Do") if not nil.
+	Examples *regexp.Regexp
+	// ExcludeExamples are the function names of the examples to exclude.
+	ExcludeExamples []string
+	// ExampleOrder is the order of the examples, "name" (default) or
+	// "source".
+	ExampleOrder string
+	// Tags are the build tags selecting the files of the package. If nil,
+	// all the files are parsed regardless of their build constraints.
+	Tags []string
+	// GOOS and GOARCH are the target platform selecting the files of the
+	// package by their names and build constraints, such as _windows.go.
+	// If both are empty and Tags is nil, all the files are parsed.
+	GOOS   string
+	GOARCH string
+	// NoCgo documents the package as built with cgo disabled, skipping the
+	// files importing "C". Otherwise the files for builds without cgo are
+	// skipped if they conflict. See filterCgoFiles.
+	NoCgo bool
+	// ConfigStruct is the name of the configuration struct of the package.
+	// If empty, the one marked by configDirective is used.
+	ConfigStruct string
+	// DockerImage is the Docker image of the package. If empty,
+	// it is detected from the GoReleaser configuration or the Dockerfile.
+	DockerImage string
+	// Badges are the user-defined badges.
+	Badges []Badge
+	// BadgeOrder are the kinds of badges in the order to show.
+	BadgeOrder []string
+	// DisableBadges are the kinds of badges not to detect.
+	DisableBadges []string
+	// BadgeStyle is the style of the badges of shields.io.
+	BadgeStyle string
+	// SelfHosted are the kinds of self-hosted services, e.g. "gitlab",
+	// by their hosts.
+	SelfHosted map[string]string
+	// RepositoryURL is the URL of the repository of the package. If empty,
+	// it is derived from the import path.
+	RepositoryURL string
+	// ResolveVanity looks up the repository of a vanity import path by its
+	// go-import meta tags over the network if RepositoryURL is empty.
+	ResolveVanity bool
+	// Branch is the branch the badges show the status of. If empty,
+	// the default branch of the repository is used.
+	Branch string
+	// Remote is true if the package is downloaded from the module proxy or
+	// cloned from a git URL, so that the user is not its author.
+	Remote bool
+	// ModuleVersion is the version of the module downloaded from the module
+	// proxy, which is not a git repository. See fetchPackage.
+	ModuleVersion string
+}
+
+// loadReadme parses the package in dir and collects the information
+// for its README.
+func loadReadme(dir string, opts loadOptions) (*Readme, error) {
+	fset := token.NewFileSet()
+	ctx := buildContext(opts.Tags, opts.GOOS, opts.GOARCH)
+	if ctx != nil && !opts.NoCgo {
+		ctx.BuildTags = append(ctx.BuildTags[:len(ctx.BuildTags):len(ctx.BuildTags)], "cgo")
+	}
+	pkgs, err := parseDir(fset, dir, "", ctx)
+	if err != nil {
+		return nil, err
+	}
+	filterCgoFiles(pkgs, !opts.NoCgo)
+
+	bpkg, err := importDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Readme{fset: fset, Sections: DefaultSections}
+
+	// collected before doc.New, which drops doc comments from the AST
+	r.Benchmarks = collectTestFuncs(pkgs, "Benchmark")
+	r.FuzzTargets = collectTestFuncs(pkgs, "Fuzz")
+	if pkg, ok := pkgs["main"]; ok {
+		r.Commands = cobraCommands(fset, pkgFiles(pkg))
+		if len(r.Commands) == 0 {
+			r.Commands = cliCommands(fset, pkgFiles(pkg), path.Base(bpkg.ImportPath))
+		}
+		if len(r.Commands) == 0 {
+			// pflag methods look like the ones of flag
+			r.Flags = commandFlags(fset, pkgFiles(pkg))
+		}
+		r.ExitCodes = exitCodes(fset, pkgFiles(pkg))
+	}
+	var allFiles []*ast.File
+	for _, pkg := range sortedPackages(pkgs) {
+		allFiles = append(allFiles, pkgFiles(pkg)...)
+	}
+	r.Env = envVars(fset, allFiles)
+	if r.ConfigFields, err = configFields(fset, allFiles, opts.ConfigStruct); err != nil {
+		return nil, err
+	}
+
+	docPkg := documentedPackage(dir, pkgs)
+	var files []*ast.File
+	for _, pkg := range sortedPackages(pkgs) {
+		files = append(files, pkgFiles(pkg)...)
+
+		excluded := ignoredFuncs(pkgFiles(pkg))
+		for _, name := range opts.ExcludeExamples {
+			excluded[name] = true
+		}
+
+		exs := doc.Examples(pkgFiles(pkg)...)
+		for _, ex := range exs {
+			if opts.Examples != nil && !opts.Examples.MatchString("Example"+ex.Name) || excluded["Example"+ex.Name] {
+				continue
+			}
+
+			// Use the doc (if any)
+			if ex.Name == "" && ex.Doc != "" {
+				ex.Name = strings.TrimSpace(strings.TrimPrefix(ex.Doc, "Example:"))
+				if ex.Play != nil {
+					// If the example was whole file, remove the doc comment from the example function
+					for _, d := range ex.Play.Decls {
+						if f, ok := d.(*ast.FuncDecl); ok && f.Name.Name == "main" {
+							for i, c := range ex.Comments {
+								if c.Text() == ex.Doc {
+									ex.Comments = append(ex.Comments[0:i], ex.Comments[i+1:len(ex.Comments)]...)
+									break
+								}
+							}
+						}
+					}
+				}
+			}
+			r.Examples = append(r.Examples, ex)
+		}
+
+		if pkg == docPkg {
+			r.Pkg = doc.New(pkg, bpkg.ImportPath, opts.Mode)
+		}
+	}
+
+	if r.Pkg == nil {
+		return nil, withStatus(exitParseError, fmt.Errorf("no source found"))
+	}
+
+	if err := sortExamples(fset, r.Examples, opts.ExampleOrder); err != nil {
+		return nil, err
+	}
+
+	for _, v := range append(r.Pkg.Consts, r.Pkg.Vars...) {
+		r.Exports = append(r.Exports, v.Names...)
+	}
+
+	for _, f := range r.Pkg.Funcs {
+		r.Exports = append(r.Exports, f.Name)
+	}
+
+	for _, t := range r.Pkg.Funcs {
+		r.Exports = append(r.Exports, t.Name)
+	}
+
+	r.ExampleDeps, err = exampleDeps(bpkg.Dir, files, r.Examples)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.ExampleDeps) > 0 {
+		log.Printf("warning: examples import packages not required by go.mod: %s", strings.Join(r.ExampleDeps, ", "))
+	}
+
+	if opts.RepositoryURL != "" {
+		r.Repository = repositoryFromURL(opts.RepositoryURL, opts.SelfHosted)
+		if r.Repository == nil {
+			return nil, withStatus(exitUsage, fmt.Errorf("unknown repository host: %s", opts.RepositoryURL))
+		}
+	} else if opts.ResolveVanity {
+		r.Repository, err = resolveRepositoryOf(bpkg.ImportPath, opts.SelfHosted)
+		if err != nil {
+			log.Printf("warning: %v", err)
+		}
+	} else {
+		r.Repository = resolveRepository(bpkg.ImportPath, opts.SelfHosted)
+	}
+	if root := repoRoot(bpkg.Dir); root != "" {
+		if abs, err := filepath.Abs(bpkg.Dir); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil && rel != "." {
+				r.pkgDir = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	goreleaser := loadGoReleaserConfig(bpkg.Dir)
+
+	dockerImage := opts.DockerImage
+	if dockerImage == "" && goreleaser != nil {
+		dockerImage = goreleaser.dockerImage()
+	}
+	r.DockerImage = dockerImage
+	if r.DockerImage == "" {
+		r.DockerImage = detectDockerImage(bpkg.Dir, r.Repository)
+	}
+
+	r.License = detectLicense(bpkg.Dir)
+	r.ContributingPath = detectCommunityFile(bpkg.Dir, contributingFileNames...)
+	r.CodeOfConductPath = detectCommunityFile(bpkg.Dir, codeOfConductFileNames...)
+	r.Version = currentVersion(bpkg.Dir)
+	if opts.ModuleVersion != "" {
+		r.Version = opts.ModuleVersion
+	}
+	r.GoVersion, r.Toolchain = goRequirements(bpkg.Dir)
+	r.Platforms = detectPlatforms(bpkg.Dir, goreleaser)
+	r.Binaries = cmdBinaries(bpkg.Dir, bpkg.ImportPath, r.GoVersion, r.Version)
+	if r.Repository != nil {
+		r.ReleasesURL = r.Repository.Forge().ReleasesLink(*r.Repository)
+	}
+	if goreleaser != nil && r.IsCommand() {
+		project := filepath.Base(bpkg.Dir)
+		var assetURL func(tag, name string) string
+		if repo := r.Repository; repo != nil {
+			project = path.Base(repo.Path)
+			assetURL = func(tag, name string) string {
+				return repo.Forge().AssetLink(*repo, tag, name)
+			}
+		}
+		r.Downloads = goreleaser.downloads(project, r.Version, assetURL)
+		r.Homebrew, r.HomebrewCask = goreleaser.homebrew(project)
+	}
+	r.ChangelogURL = detectCommunityFile(bpkg.Dir, changelogFileNames...)
+	if r.ChangelogURL == "" && r.Repository != nil {
+		if tag, err := latestRelease(bpkg.Dir); err == nil && tag != "" {
+			r.ChangelogURL = r.Repository.Forge().ReleasesLink(*r.Repository)
+		}
+	}
+	if r.Sponsors, err = detectSponsors(bpkg.Dir); err != nil {
+		log.Printf("warning: %v", err)
+	}
+
+	// Collect badges
+	disabled := opts.DisableBadges
+	if dockerImage != "" {
+		// the configured image replaces the detected one
+		disabled = append(disabled[:len(disabled):len(disabled)], "docker")
+	}
+	target := BadgeTarget{Dir: bpkg.Dir, ImportPath: bpkg.ImportPath, Repo: r.Repository, Branch: opts.Branch}
+	if target.Branch == "" {
+		fallback := "master"
+		if r.Repository != nil {
+			fallback = r.Repository.Forge().DefaultBranch()
+		}
+		target.Branch = defaultBranch(bpkg.Dir, fallback)
+	}
+	r.Branch = target.Branch
+	badges := detectBadges(target, disabled)
+	if dockerImage != "" && !containsString(opts.DisableBadges, "docker") {
+		badges = append(badges, dockerBadges(dockerImage)...)
+	}
+	badges = append(badges, opts.Badges...)
+	sortBadges(badges, opts.BadgeOrder)
+	for _, b := range badges {
+		r.Badges = append(r.Badges, b.Markdown(opts.BadgeStyle))
+	}
+
+	if !opts.Remote {
+		_ = gitconfig.Config{
+			Source: gitconfig.SourceDefault,
+			Dir:    bpkg.Dir,
+		}.Load(&r.Author)
+	}
+	if r.Author.Name == "" {
+		if r.Repository != nil {
+			r.Author = r.Repository.Forge().AuthorFromPath(*r.Repository)
+		} else if a, ok := topCommitAuthor(bpkg.Dir); ok {
+			r.Author = a
+		}
+	}
+
+	r.Authors = readPeopleFile(bpkg.Dir, r.Repository, authorsFileNames...)
+	r.Maintainers = readPeopleFile(bpkg.Dir, r.Repository, maintainersFileNames...)
+	if len(r.Maintainers) == 0 {
+		r.Maintainers = codeOwners(bpkg.Dir, r.pkgDir, r.Repository)
+	}
+
+	return r, nil
+}
+
+// pkgFiles returns the files of pkg sorted by their names, so that what is
+// collected from them is in the same order every time.
+func pkgFiles(pkg *ast.Package) []*ast.File {
+	names := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ff := make([]*ast.File, 0, len(names))
+	for _, name := range names {
+		ff = append(ff, pkg.Files[name])
+	}
+	return ff
+}
+
+// sortedPackages returns pkgs sorted by their names, e.g. "foo", "foo_test"
+// and "main".
+func sortedPackages(pkgs map[string]*ast.Package) []*ast.Package {
+	names := make([]string, 0, len(pkgs))
+	for name := range pkgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sorted := make([]*ast.Package, 0, len(names))
+	for _, name := range names {
+		sorted = append(sorted, pkgs[name])
+	}
+	return sorted
+}
+
+// findGoMod looks for go.mod in dir and its ancestors.
+func findGoMod(dir string) string {
+	for {
+		path := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ignoreDirective excludes the function from the README,
+// typically an example, if it is in its doc comment.
+const ignoreDirective = "//goreadme:ignore"
+
+// ignoredFuncs returns the names of the functions in files marked by ignoreDirective.
+func ignoredFuncs(files []*ast.File) map[string]bool {
+	ignored := map[string]bool{}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if ok && hasDirective(fn.Doc, ignoreDirective) {
+				ignored[fn.Name.Name] = true
+			}
+		}
+	}
+	return ignored
+}
+
+// sortExamples sorts exs by their names, or by their positions in the source
+// if order is "source". The examples are collected from the package and
+// the external test package in no particular order.
+func sortExamples(fset *token.FileSet, exs []*doc.Example, order string) error {
+	switch order {
+	case "", "name":
+		sort.SliceStable(exs, func(i, j int) bool {
+			return exs[i].Name < exs[j].Name
+		})
+	case "source":
+		sort.SliceStable(exs, func(i, j int) bool {
+			pi, pj := fset.Position(exs[i].Code.Pos()), fset.Position(exs[j].Code.Pos())
+			if pi.Filename != pj.Filename {
+				return pi.Filename < pj.Filename
+			}
+			return pi.Offset < pj.Offset
+		})
+	default:
+		return withStatus(exitUsage, fmt.Errorf("invalid example order: %q", order))
+	}
+	return nil
+}
+
+// exampleDeps returns the third-party packages imported by the examples
+// which are not covered by the requirements in go.mod. The imports of
+// the examples which are not playable are the ones of their files.
+// Returns nil if the package does not belong to a module.
+func exampleDeps(dir string, files []*ast.File, exs []*doc.Example) ([]string, error) {
+	path := findGoMod(dir)
+	if path == "" {
+		return nil, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mf, err := modfile.ParseLax(path, b, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	mods := []string{}
+	if mf.Module != nil {
+		mods = append(mods, mf.Module.Mod.Path)
+	}
+	for _, req := range mf.Require {
+		mods = append(mods, req.Mod.Path)
+	}
+
+	required := func(importPath string) bool {
+		for _, mod := range mods {
+			if importPath == mod || strings.HasPrefix(importPath, mod+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	seen := map[string]bool{}
+	deps := []string{}
+	for _, ex := range exs {
+		var imports []*ast.ImportSpec
+		if ex.Play != nil {
+			// only the ones the example uses; the synthesized file does
+			// not have Imports set
+			for _, d := range ex.Play.Decls {
+				if g, ok := d.(*ast.GenDecl); ok && g.Tok == token.IMPORT {
+					for _, spec := range g.Specs {
+						imports = append(imports, spec.(*ast.ImportSpec))
+					}
+				}
+			}
+		} else {
+			for _, f := range files {
+				if f.Pos() <= ex.Code.Pos() && ex.Code.Pos() <= f.End() {
+					imports = f.Imports
+					break
+				}
+			}
+		}
+
+		for _, imp := range imports {
+			importPath := strings.Trim(imp.Path.Value, "\"`")
+			// standard packages do not have dots in their first element
+			if !strings.Contains(strings.SplitN(importPath, "/", 2)[0], ".") {
+				continue
+			}
+			if required(importPath) || seen[importPath] {
+				continue
+			}
+			seen[importPath] = true
+			deps = append(deps, importPath)
+		}
+	}
+
+	sort.Strings(deps)
+
+	return deps, nil
+}
@@ -0,0 +1,759 @@
+package main
+
+import (
+	"errors"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIgnoredFuncs(t *testing.T) {
+	src := `package foo_test
+
+// ExampleA is shown.
+func ExampleA() {}
+
+// ExampleB is internal.
+//
+//goreadme:ignore
+func ExampleB() {}
+
+//goreadme:ignore
+func ExampleC() {}
+`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo_test.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := ignoredFuncs([]*ast.File{f})
+	expected := map[string]bool{"ExampleB": true, "ExampleC": true}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ignoredFuncs = %v, expected %v", got, expected)
+	}
+}
+
+func TestGoRequirements(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	gomod := "module example.com/foo\n\ngo 1.21\n\ntoolchain go1.22.3\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	goVersion, toolchain := goRequirements(sub)
+	if goVersion != "1.21" || toolchain != "go1.22.3" {
+		t.Errorf("goRequirements() = %q, %q, expected %q, %q", goVersion, toolchain, "1.21", "go1.22.3")
+	}
+}
+
+func TestReadme_InstallCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		goVersion string
+		version   string
+		expected  string
+	}{
+		{"foo", "1.21", "v1.2.3", "go get example.com/foo"},
+		{"main", "1.21", "v1.2.3", "go install example.com/foo@v1.2.3"},
+		{"main", "", "", "go install example.com/foo@latest"},
+		{"main", "1.13", "v1.2.3", "go get -u example.com/foo"},
+	}
+
+	for _, test := range tests {
+		r := Readme{
+			Pkg:       &doc.Package{Name: test.name, ImportPath: "example.com/foo"},
+			GoVersion: test.goVersion,
+			Version:   test.version,
+		}
+		if got := r.InstallCommand(); got != test.expected {
+			t.Errorf("InstallCommand() with %+v = %q, expected %q", test, got, test.expected)
+		}
+	}
+}
+
+func TestExampleDeps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	gomod := "module example.com/foo\n\nrequire github.com/required/x v1.0.0\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := findGoMod(sub); got != filepath.Join(dir, "go.mod") {
+		t.Errorf("findGoMod(%q) = %q, expected %q", sub, got, filepath.Join(dir, "go.mod"))
+	}
+
+	src := `package sub_test
+
+import (
+	"fmt"
+	"testing"
+
+	"example.com/foo/sub"
+	"github.com/extra/y"
+	"github.com/required/x/v"
+	"github.com/unrelated/z"
+)
+
+func ExampleFoo() {
+	fmt.Println(sub.Foo(), v.V)
+	// Output: foo
+}
+
+func ExampleBar() {
+	fmt.Println(sub.Bar(), y.Y)
+	// Output: bar
+}
+
+func TestBaz(t *testing.T) {
+	z.Z(t)
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filepath.Join(sub, "sub_test.go"), src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := exampleDeps(sub, []*ast.File{f}, doc.Examples(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"github.com/extra/y"}; !reflect.DeepEqual(deps, expected) {
+		t.Errorf("exampleDeps = %q, expected %q", deps, expected)
+	}
+
+	// the examples which are not playable have the imports of their files
+	exs := doc.Examples(f)
+	for _, ex := range exs {
+		ex.Play = nil
+	}
+	deps, err = exampleDeps(sub, []*ast.File{f}, exs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"github.com/extra/y", "github.com/unrelated/z"}; !reflect.DeepEqual(deps, expected) {
+		t.Errorf("exampleDeps = %q, expected %q", deps, expected)
+	}
+
+	if deps, err := exampleDeps(os.TempDir(), nil, nil); err != nil || deps != nil {
+		t.Errorf("exampleDeps outside modules = %q, %v, expected nil", deps, err)
+	}
+}
+
+// testReadme loads the README data of the module "foo/bar" with files,
+// which are written in a temporary directory removed at the end of t.
+func testReadme(t *testing.T, files map[string]string, opts loadOptions) *Readme {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	if _, ok := files["go.mod"]; !ok {
+		if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module foo/bar\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := loadReadme(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// renderSections renders the sections of r by the default template, with
+// the title and the surrounding empty lines dropped.
+func renderSections(t *testing.T, r *Readme, sections ...string) string {
+	r.Sections = sections
+	out, err := render(r, DefaultTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// drop the title
+	if i := strings.Index(out, "\n"); i != -1 {
+		out = out[i:]
+	}
+	if out = strings.TrimSpace(out); out != "" {
+		out += "\n"
+	}
+	return out
+}
+
+func TestParseSections(t *testing.T) {
+	without := func(names ...string) []string {
+		var sections []string
+		for _, s := range DefaultSections {
+			excluded := false
+			for _, name := range names {
+				excluded = excluded || s == name
+			}
+			if !excluded {
+				sections = append(sections, s)
+			}
+		}
+		return sections
+	}
+
+	tests := []struct {
+		names    []string
+		expected []string
+	}{
+		{[]string{}, DefaultSections},
+		{[]string{"-examples"}, without("examples")},
+		{[]string{"-examples", "-author"}, without("examples", "author")},
+		{[]string{"author", "doc"}, []string{"author", "doc"}},
+		// excluded ones are removed from the listed ones
+		{[]string{"doc", "examples", "-examples", "todo"}, []string{"doc", "todo"}},
+		{[]string{"-doc", "doc"}, []string{}},
+		// excluding a section not generated by default
+		{[]string{"-api"}, without("api")},
+	}
+	for _, test := range tests {
+		got, err := parseSections(test.names)
+		if err != nil {
+			t.Errorf("parseSections(%q): %v", test.names, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("parseSections(%q) = %q, expected %q", test.names, got, test.expected)
+		}
+
+		r := Readme{Sections: got}
+		for _, s := range AllSections {
+			expected := false
+			for _, e := range test.expected {
+				expected = expected || s == e
+			}
+			if r.HasSection(s) != expected {
+				t.Errorf("parseSections(%q): HasSection(%q) = %v, expected %v", test.names, s, !expected, expected)
+			}
+		}
+	}
+
+	for _, names := range [][]string{{"unknown"}, {"-unknown"}, {"doc", "-"}, {"--doc"}} {
+		if _, err := parseSections(names); err == nil {
+			t.Errorf("parseSections(%q) should fail for unknown sections", names)
+		}
+	}
+}
+
+const testAPISource = `// Package bar does bar.
+package bar
+
+import "io"
+
+// Version is the version.
+const Version = "1.0"
+
+// Level is a level.
+type Level int
+
+// Levels.
+const (
+	Debug Level = iota
+	Info
+)
+
+// ErrClosed is returned after Close.
+var ErrClosed = io.EOF
+
+// Doer does something.
+type Doer interface {
+	// Do does something.
+	Do() error
+}
+
+// Client is a client.
+type Client struct {
+	// Name is the name.
+	Name string
+}
+
+// New returns a Client.
+func New() *Client { return nil }
+
+// Do does something.
+func (c *Client) Do() error { return nil }
+
+// Parse parses s.
+func Parse(s string) (Level, error) { return 0, nil }
+`
+
+func TestSection_api(t *testing.T) {
+	r := testReadme(t, map[string]string{"bar.go": testAPISource}, loadOptions{})
+
+	var names []string
+	for _, f := range r.Funcs() {
+		names = append(names, f.Name)
+	}
+	if expected := []string{"New", "Parse"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Funcs = %q, expected %q", names, expected)
+	}
+
+	names = nil
+	for _, t := range r.Interfaces() {
+		names = append(names, t.Name)
+	}
+	if expected := []string{"Doer"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Interfaces = %q, expected %q", names, expected)
+	}
+
+	for _, test := range []struct {
+		section  string
+		expected string
+	}{
+		{"api", `## API
+
+### func New
+
+~~~go
+func New() *Client
+~~~
+
+New returns a Client.
+
+### func Parse
+
+~~~go
+func Parse(s string) (Level, error)
+~~~
+
+Parse parses s.
+`},
+		{"interfaces", `## Interfaces
+
+### Doer
+
+Doer does something.
+
+~~~go
+type Doer interface {
+    // Do does something.
+    Do() error
+}
+~~~
+`},
+		{"types", `## Types
+
+### type Client
+
+~~~go
+type Client struct {
+    // Name is the name.
+    Name string
+}
+~~~
+
+Client is a client.
+
+#### func New
+
+~~~go
+func New() *Client
+~~~
+
+New returns a Client.
+
+#### func (*Client) Do
+
+~~~go
+func (c *Client) Do() error
+~~~
+
+Do does something.
+
+### type Doer
+
+~~~go
+type Doer interface {
+    // Do does something.
+    Do() error
+}
+~~~
+
+Doer does something.
+
+### type Level
+
+~~~go
+type Level int
+~~~
+
+Level is a level.
+
+#### func Parse
+
+~~~go
+func Parse(s string) (Level, error)
+~~~
+
+Parse parses s.
+`},
+	} {
+		// fences are replaced to be written in raw strings
+		got := strings.Replace(renderSections(t, r, test.section), "```", "~~~", -1)
+		if got != test.expected {
+			t.Errorf("%s section:\nGot ---\n%s\nExpected ---\n%s", test.section, got, test.expected)
+		}
+	}
+}
+
+func TestSection_values(t *testing.T) {
+	r := testReadme(t, map[string]string{"bar.go": testAPISource}, loadOptions{})
+
+	// fences are replaced to be written in raw strings
+	got := strings.Replace(renderSections(t, r, "values"), "```", "~~~", -1)
+	expected := `## Constants
+
+~~~go
+const Version = "1.0"
+~~~
+
+Version is the version.
+
+~~~go
+const (
+    Debug Level = iota
+    Info
+)
+~~~
+
+Levels.
+
+## Variables
+
+~~~go
+var ErrClosed = io.EOF
+~~~
+
+ErrClosed is returned after Close.
+`
+	if got != expected {
+		t.Errorf("values section:\nGot ---\n%s\nExpected ---\n%s", got, expected)
+	}
+}
+
+func TestReadme_Interfaces(t *testing.T) {
+	r := testReadme(t, map[string]string{"bar.go": `package bar
+
+import "io"
+
+// Closer closes.
+type Closer interface {
+	io.Closer
+}
+
+type (
+	// Getter gets.
+	Getter interface{ Get() string }
+	// Value is a value.
+	Value struct{}
+)
+
+// Func is a function.
+type Func func()
+
+type hidden interface{}
+`}, loadOptions{})
+
+	var names []string
+	for _, t := range r.Interfaces() {
+		names = append(names, t.Name)
+	}
+	if expected := []string{"Closer", "Getter"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Interfaces = %q, expected %q", names, expected)
+	}
+}
+
+func TestSection_examples(t *testing.T) {
+	r := testReadme(t, map[string]string{
+		"bar.go": "package bar\n\nfunc Hello() string { return \"hello\" }\n",
+		"example_test.go": `package bar_test
+
+import (
+	"fmt"
+
+	"foo/bar"
+)
+
+func ExampleHello() {
+	fmt.Println(bar.Hello())
+	// Output: hello
+}
+`,
+	}, loadOptions{})
+
+	tests := []struct {
+		collapse bool
+		expected string
+	}{
+		{false, "## Examples\n\n### Hello\n\n~~~go\npackage main\n\nimport (\n    \"fmt\"\n\n    \"foo/bar\"\n)\n\nfunc main() {\n    fmt.Println(bar.Hello())\n}\n~~~\n\nOutput:\n\n~~~\nhello\n~~~\n"},
+		{true, "## Examples\n\n### Hello\n\n~~~go\npackage main\n\nimport (\n    \"fmt\"\n\n    \"foo/bar\"\n)\n\nfunc main() {\n    fmt.Println(bar.Hello())\n}\n~~~\n\n<details>\n<summary>Output</summary>\n\n~~~\nhello\n~~~\n\n</details>\n"},
+	}
+	for _, test := range tests {
+		r.CollapseOutput = test.collapse
+		expected := strings.Replace(test.expected, "~~~", "```", -1)
+		if got := renderSections(t, r, "examples"); got != expected {
+			t.Errorf("examples section with CollapseOutput=%v:\nGot ---\n%s\nExpected ---\n%s", test.collapse, got, expected)
+		}
+	}
+}
+
+func TestLoadReadme_examples(t *testing.T) {
+	files := map[string]string{
+		"bar.go": "package bar\n\nfunc New() {}\n\nfunc NewClient() {}\n",
+		"example_test.go": `package bar_test
+
+import "fmt"
+
+// Example: Basic usage
+func Example() {
+	fmt.Println("basic")
+}
+
+func ExampleNew() {
+	fmt.Println("new")
+}
+
+func ExampleNew_retry() {
+	fmt.Println("retry")
+}
+
+func ExampleNewClient() {
+	fmt.Println("client")
+}
+`,
+	}
+
+	tests := []struct {
+		pattern  string
+		exclude  []string
+		expected []string
+	}{
+		{"", nil, []string{"Basic usage", "New", "NewClient", "New_retry"}},
+		// the whole name should match
+		{"ExampleNew", nil, []string{"New"}},
+		{"ExampleNew.*", nil, []string{"New", "NewClient", "New_retry"}},
+		{"ExampleNew|ExampleNew_retry", nil, []string{"New", "New_retry"}},
+		// the unnamed example is titled by its doc
+		{"Example", nil, []string{"Basic usage"}},
+		{"", []string{"Example", "ExampleNew_retry"}, []string{"New", "NewClient"}},
+	}
+	for _, test := range tests {
+		opts := loadOptions{ExcludeExamples: test.exclude}
+		if test.pattern != "" {
+			rx, err := examplesRegexp(test.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts.Examples = rx
+		}
+
+		r := testReadme(t, files, opts)
+		var names []string
+		for _, ex := range r.Examples {
+			names = append(names, ex.Name)
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("examples by %q excluding %q = %q, expected %q", test.pattern, test.exclude, names, test.expected)
+		}
+	}
+
+	_, err := examplesRegexp("Example(")
+	var e *exitError
+	if !errors.As(err, &e) || e.status != exitUsage {
+		t.Errorf("examplesRegexp(%q) = %v, expected a usage error", "Example(", err)
+	}
+}
+
+func TestSortExamples(t *testing.T) {
+	sources := []struct{ name, src string }{
+		{"b_test.go", "package foo_test\n\nfunc ExampleC() {}\n\nfunc ExampleA() {}\n"},
+		{"a_test.go", "package foo_test\n\nfunc ExampleD() {}\n\nfunc ExampleB() {}\n"},
+	}
+
+	tests := []struct {
+		order    string
+		expected []string
+	}{
+		{"", []string{"A", "B", "C", "D"}},
+		{"name", []string{"A", "B", "C", "D"}},
+		{"source", []string{"D", "B", "C", "A"}},
+	}
+	for _, test := range tests {
+		fset := token.NewFileSet()
+		var exs []*doc.Example
+		for _, s := range sources {
+			f, err := parser.ParseFile(fset, s.name, s.src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exs = append(exs, doc.Examples(f)...)
+		}
+
+		if err := sortExamples(fset, exs, test.order); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, ex := range exs {
+			names = append(names, ex.Name)
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("examples in %q order = %q, expected %q", test.order, names, test.expected)
+		}
+	}
+
+	err := sortExamples(token.NewFileSet(), nil, "random")
+	var e *exitError
+	if !errors.As(err, &e) || e.status != exitUsage {
+		t.Errorf("sortExamples(%q) = %v, expected a usage error", "random", err)
+	}
+}
+
+func TestPkgFiles(t *testing.T) {
+	pkg := &ast.Package{Name: "foo", Files: map[string]*ast.File{}}
+	for _, name := range []string{"c.go", "a.go", "b_test.go", "b.go"} {
+		pkg.Files[name] = &ast.File{Name: ast.NewIdent(name)}
+	}
+
+	var names []string
+	for _, f := range pkgFiles(pkg) {
+		names = append(names, f.Name.Name)
+	}
+	if expected := []string{"a.go", "b.go", "b_test.go", "c.go"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("pkgFiles = %q, expected %q", names, expected)
+	}
+}
+
+func TestLoadReadme_ignoredMain(t *testing.T) {
+	files := map[string]string{
+		"foo.go": "// Package foo is foo.\npackage foo\n",
+		"gen.go": "//go:build ignore\n\n// Command gen generates foo.\npackage main\n\nfunc main() {}\n",
+	}
+	for i := 0; i < 5; i++ {
+		if r := testReadme(t, files, loadOptions{}); r.Pkg.Name != "foo" {
+			t.Fatalf("loadReadme documents package %s, expected foo", r.Pkg.Name)
+		}
+	}
+}
+
+func TestRender_deterministic(t *testing.T) {
+	files := map[string]string{
+		"bar.go": `// Package bar does things.
+package bar
+
+// Client talks to the server.
+type Client struct{}
+
+// Do does a request.
+func (c *Client) Do() error { return nil }
+
+// New returns a new Client.
+func New() *Client { return &Client{} }
+`,
+		"errors.go": `package bar
+
+import "errors"
+
+// Errors returned by Client.
+var (
+	ErrClosed  = errors.New("closed")
+	ErrTimeout = errors.New("timeout")
+)
+`,
+		"gen.go": `//go:build ignore
+
+package main
+
+import "os"
+
+func main() { println(os.Getenv("BAR_GEN_OUT")) }
+`,
+		"options.go": `package bar
+
+import "os"
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithRetry makes a Client retry n times.
+func WithRetry(n int) Option { return nil }
+
+// BAR_DEBUG enables debug logs.
+var debug = os.Getenv("BAR_DEBUG") != ""
+`,
+		"example_test.go": `package bar_test
+
+import "fmt"
+
+func ExampleNew() {
+	fmt.Println("new")
+}
+
+func ExampleClient_Do() {
+	fmt.Println("do")
+}
+`,
+		"options_example_test.go": `package bar_test
+
+import "fmt"
+
+func ExampleWithRetry() {
+	fmt.Println("retry")
+}
+
+func Example_options() {
+	fmt.Println("options")
+}
+`,
+	}
+
+	sections := append([]string{"index", "api", "interfaces", "types", "values"}, DefaultSections...)
+	for _, order := range []string{"name", "source"} {
+		var first string
+		for i := 0; i < 5; i++ {
+			out := renderSections(t, testReadme(t, files, loadOptions{ExampleOrder: order}), sections...)
+			if i == 0 {
+				first = out
+			} else if out != first {
+				t.Fatalf("render #%d with -example-order %s differs:\nGot ---\n%s\nExpected ---\n%s", i+1, order, out, first)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"text/template"
+)
+
+var releaseBodyTemplate = template.Must(template.New("release-body").Funcs(template.FuncMap{
+	"apidiff": renderAPIDiff,
+}).Parse(`{{.Overview}}
+{{if .Diff.Empty}}
+No API changes since {{.From}}.
+{{else}}
+## API changes since {{.From}}
+
+{{.Diff|apidiff}}
+{{end}}`))
+
+// runReleaseBody implements "goreadme release-body [-from REF] [-to REF] [dir]",
+// which prints a Markdown text suitable for release notes, consisting of
+// the package overview and the API changes between two git revisions.
+//
+//	goreadme release-body > notes.md && gh release create v1.2.0 --notes-file notes.md
+func runReleaseBody(args []string) error {
+	flags := flag.NewFlagSet("release-body", flag.ContinueOnError)
+	from := flags.String("from", "", "base git revision (default: the latest tag before -to)")
+	to := flags.String("to", "HEAD", "target git revision")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	dir := "."
+	if flags.NArg() >= 1 {
+		dir = flags.Arg(0)
+	}
+
+	if *from == "" {
+		tag, err := latestTag(dir, *to+"^")
+		if err != nil {
+			return err
+		}
+		*from = tag
+	}
+
+	body, err := releaseBody(dir, *from, *to)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.WriteString(body)
+	return err
+}
+
+// releaseBody renders release notes of the package in dir for the changes
+// between the git revisions from and to.
+func releaseBody(dir, from, to string) (string, error) {
+	_, pkg, err := loadDocPackage(dir, to, 0)
+	if err != nil {
+		return "", err
+	}
+
+	diff, err := diffAPIBetween(dir, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	err = releaseBodyTemplate.Execute(&buf, map[string]interface{}{
+		"Overview": renderMarkdown(pkg.Doc, pkg, markdownOptions{HeadingLevel: 2}),
+		"From":     from,
+		"Diff":     diff,
+	})
+	if err != nil {
+		return "", withStatus(exitTemplate, err)
+	}
+
+	return squeezeEmptyLines(buf.String()), nil
+}
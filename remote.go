@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/zip"
+)
+
+// rxGitURL matches the URLs of git repositories, including scp-like ones
+// such as "git@github.com:motemen/goreadme.git".
+var rxGitURL = regexp.MustCompile(`^(?:(?:https?|git|ssh|file)://|[\w.-]+@[\w.-]+:)`)
+
+// isGitURL reports whether arg is the URL of a git repository rather than
+// a directory, optionally followed by "#REF".
+func isGitURL(arg string) bool {
+	return rxGitURL.MatchString(arg)
+}
+
+// cloneRepository shallow-clones the repository given as "URL" or
+// "URL#REF", where REF is a branch or a tag, into a temporary directory
+// tmp, which the caller should remove. It returns the directory of the
+// working tree.
+func cloneRepository(arg string) (tmp, dir string, err error) {
+	url, ref := arg, ""
+	if i := strings.LastIndex(arg, "#"); i != -1 {
+		url, ref = arg[:i], arg[i+1:]
+	}
+
+	tmp, err = ioutil.TempDir("", "goreadme")
+	if err != nil {
+		return "", "", err
+	}
+	dir = filepath.Join(tmp, "repo")
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	if _, err := gitOutput(tmp, append(args, "--", url, dir)...); err != nil {
+		os.RemoveAll(tmp)
+		return "", "", err
+	}
+	return tmp, dir, nil
+}
+
+// defaultGoProxy is the module proxy used if GOPROXY is not set.
+const defaultGoProxy = "https://proxy.golang.org"
+
+// isRemotePackage reports whether arg is an import path with a version
+// rather than a directory, e.g. "github.com/motemen/goreadme@v1.2.3".
+func isRemotePackage(arg string) bool {
+	if !strings.Contains(arg, "@") {
+		return false
+	}
+	_, err := os.Stat(arg)
+	return os.IsNotExist(err)
+}
+
+// goProxy returns the first module proxy in GOPROXY.
+func goProxy() (string, error) {
+	env := os.Getenv("GOPROXY")
+	if env == "" {
+		return defaultGoProxy, nil
+	}
+	for _, proxy := range strings.FieldsFunc(env, func(r rune) bool { return r == ',' || r == '|' }) {
+		if proxy != "direct" && proxy != "off" {
+			return strings.TrimSuffix(proxy, "/"), nil
+		}
+	}
+	return "", withStatus(exitUsage, fmt.Errorf("no module proxy in GOPROXY=%s", env))
+}
+
+// fetchPackage downloads the module of the package given as "PATH@VERSION"
+// from the module proxy and extracts it into a temporary directory tmp,
+// which the caller should remove. The version may be a query such as
+// "latest", which is the default, or a branch name. It returns the
+// directory of the package and the version of the module.
+func fetchPackage(arg string) (tmp, dir, version string, err error) {
+	pkgPath, query := arg, "latest"
+	if i := strings.LastIndex(arg, "@"); i != -1 {
+		pkgPath, query = arg[:i], arg[i+1:]
+	}
+
+	proxy, err := goProxy()
+	if err != nil {
+		return "", "", "", err
+	}
+	client := &http.Client{Timeout: 60 * time.Second}
+
+	// the module is the longest prefix of the path known to the proxy
+	modPath := pkgPath
+	for {
+		version, err = proxyVersion(client, proxy, modPath, query)
+		if err == nil {
+			break
+		}
+		if err != errModuleNotFound || !strings.Contains(modPath, "/") {
+			if err == errModuleNotFound {
+				err = withStatus(exitVCSOrNetwork, fmt.Errorf("module of %s@%s not found in %s", pkgPath, query, proxy))
+			}
+			return "", "", "", err
+		}
+		modPath = path.Dir(modPath)
+	}
+
+	tmp, err = ioutil.TempDir("", "goreadme")
+	if err != nil {
+		return "", "", "", err
+	}
+	if err := proxyUnzip(client, proxy, module.Version{Path: modPath, Version: version}, filepath.Join(tmp, "mod")); err != nil {
+		os.RemoveAll(tmp)
+		return "", "", "", err
+	}
+
+	dir = filepath.Join(tmp, "mod", filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(pkgPath, modPath), "/")))
+	return tmp, dir, version, nil
+}
+
+var errModuleNotFound = fmt.Errorf("module not found")
+
+// proxyVersion resolves the version query of the module modPath by proxy.
+// It returns errModuleNotFound if the proxy does not know the module.
+func proxyVersion(client *http.Client, proxy, modPath, query string) (string, error) {
+	escPath, err := module.EscapePath(modPath)
+	if err != nil {
+		return "", errModuleNotFound
+	}
+	url := proxy + "/" + escPath + "/@latest"
+	if query != "latest" {
+		escVersion, err := module.EscapeVersion(query)
+		if err != nil {
+			return "", withStatus(exitUsage, err)
+		}
+		url = proxy + "/" + escPath + "/@v/" + escVersion + ".info"
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", withStatus(exitVCSOrNetwork, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		return "", errModuleNotFound
+	default:
+		return "", withStatus(exitVCSOrNetwork, fmt.Errorf("GET %s: %s", url, resp.Status))
+	}
+
+	var info struct {
+		Version string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", withStatus(exitVCSOrNetwork, fmt.Errorf("GET %s: %v", url, err))
+	}
+	return info.Version, nil
+}
+
+// proxyUnzip downloads the zip of the module m from proxy and extracts it
+// into dir.
+func proxyUnzip(client *http.Client, proxy string, m module.Version, dir string) error {
+	escPath, err := module.EscapePath(m.Path)
+	if err != nil {
+		return err
+	}
+	escVersion, err := module.EscapeVersion(m.Version)
+	if err != nil {
+		return err
+	}
+	url := proxy + "/" + escPath + "/@v/" + escVersion + ".zip"
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return withStatus(exitVCSOrNetwork, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return withStatus(exitVCSOrNetwork, fmt.Errorf("GET %s: %s", url, resp.Status))
+	}
+
+	// zip.Unzip needs a file to read at random
+	f, err := ioutil.TempFile("", "goreadme-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return withStatus(exitVCSOrNetwork, err)
+	}
+
+	return withStatus(exitVCSOrNetwork, zip.Unzip(dir, m, f.Name()))
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchPackage(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"go.mod":     "module example.com/foo\n",
+		"foo.go":     "package foo\n",
+		"sub/sub.go": "// Package sub is sub.\npackage sub\n",
+	} {
+		w, err := zw.Create("example.com/foo@v1.2.0/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/foo/@latest", "/example.com/foo/@v/main.info":
+			w.Write([]byte(`{"Version":"v1.2.0","Time":"2024-01-01T00:00:00Z"}`))
+		case "/example.com/foo/@v/v1.2.0.zip":
+			w.Write(buf.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	defer os.Setenv("GOPROXY", os.Getenv("GOPROXY"))
+	os.Setenv("GOPROXY", ts.URL+",direct")
+
+	for _, arg := range []string{"example.com/foo/sub@latest", "example.com/foo/sub@main"} {
+		tmp, dir, version, err := fetchPackage(arg)
+		if err != nil {
+			t.Fatalf("fetchPackage(%q): %v", arg, err)
+		}
+		defer os.RemoveAll(tmp)
+
+		if version != "v1.2.0" {
+			t.Errorf("fetchPackage(%q) version = %q, expected v1.2.0", arg, version)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, "sub.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "// Package sub is sub.\npackage sub\n"; string(b) != expected {
+			t.Errorf("sub.go = %q, expected %q", b, expected)
+		}
+	}
+
+	if _, _, _, err := fetchPackage("example.com/bar@latest"); err == nil {
+		t.Errorf("fetchPackage should fail for unknown modules")
+	}
+
+	os.Setenv("GOPROXY", "off")
+	if _, _, _, err := fetchPackage("example.com/foo@latest"); err == nil {
+		t.Errorf("fetchPackage should fail with GOPROXY=off")
+	}
+}
+
+func TestRunGenerate_remotePackage(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"go.mod":         "module example.com/foo\n",
+		".gitattributes": "* text eol=crlf\n",
+		"foo.go":         "// Package foo is foo.\npackage foo\n",
+	} {
+		w, err := zw.Create("example.com/foo@v1.2.0/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/foo/@v/v1.2.0.info":
+			w.Write([]byte(`{"Version":"v1.2.0","Time":"2024-01-01T00:00:00Z"}`))
+		case "/example.com/foo/@v/v1.2.0.zip":
+			w.Write(buf.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	defer os.Setenv("GOPROXY", os.Getenv("GOPROXY"))
+	os.Setenv("GOPROXY", ts.URL)
+
+	// the line ending follows the .gitattributes of the downloaded module
+	out, err := captureStdout(t, func() error {
+		return runGenerate([]string{"-sections", "doc", "example.com/foo@v1.2.0"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "# foo\r\n\r\nPackage foo is foo.\r\n\r\n"; out != expected {
+		t.Errorf("runGenerate output = %q, expected %q", out, expected)
+	}
+}
+
+func TestIsRemotePackage(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected bool
+	}{
+		{"github.com/motemen/goreadme@v1.0.0", true},
+		{"github.com/motemen/goreadme", false},
+		{".", false},
+	}
+	for _, test := range tests {
+		if got := isRemotePackage(test.arg); got != test.expected {
+			t.Errorf("isRemotePackage(%q) = %v, expected %v", test.arg, got, test.expected)
+		}
+	}
+}
+
+func TestCloneRepository(t *testing.T) {
+	src, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	git := func(args ...string) {
+		if _, err := gitOutput(src, append([]string{"-c", "user.name=alice", "-c", "user.email=alice@example.com"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	if err := ioutil.WriteFile(filepath.Join(src, "foo.go"), []byte("package foo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	git("tag", "v1.0.0")
+	if err := ioutil.WriteFile(filepath.Join(src, "foo.go"), []byte("package foo // changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("commit", "-q", "-a", "-m", "second")
+
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"", "package foo // changed\n"},
+		{"#v1.0.0", "package foo\n"},
+	}
+	for _, test := range tests {
+		url := "file://" + filepath.ToSlash(src) + test.ref
+		if !isGitURL(url) {
+			t.Errorf("isGitURL(%q) = false", url)
+		}
+
+		tmp, dir, err := cloneRepository(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tmp)
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "foo.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.expected {
+			t.Errorf("foo.go of %q = %q, expected %q", url, b, test.expected)
+		}
+	}
+
+	if _, _, err := cloneRepository("file://" + filepath.ToSlash(src) + "#nope"); err == nil {
+		t.Errorf("cloneRepository should fail for unknown refs")
+	}
+}
+
+func TestRunGenerate_gitURL(t *testing.T) {
+	root, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	src := filepath.Join(root, "src")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"go.mod":         "module example.com/foo\n",
+		".gitattributes": "* text eol=crlf\n",
+		"foo.go":         "// Package foo is foo.\npackage foo\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git := func(args ...string) {
+		if _, err := gitOutput(src, append([]string{"-c", "user.name=alice", "-c", "user.email=alice@example.com"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	bare := filepath.Join(root, "foo.git")
+	git("clone", "-q", "--bare", src, bare)
+
+	// the line ending follows the .gitattributes of the cloned repository
+	out, err := captureStdout(t, func() error {
+		return runGenerate([]string{"-sections", "doc", "file://" + filepath.ToSlash(bare)})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "# foo\r\n\r\nPackage foo is foo.\r\n\r\n"; out != expected {
+		t.Errorf("runGenerate output = %q, expected %q", out, expected)
+	}
+}
+
+func TestIsGitURL(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected bool
+	}{
+		{"https://github.com/motemen/goreadme.git", true},
+		{"git@github.com:motemen/goreadme.git", true},
+		{"ssh://git@example.com/foo/bar", true},
+		{"github.com/motemen/goreadme@v1.0.0", false},
+		{"./sub", false},
+	}
+	for _, test := range tests {
+		if got := isGitURL(test.arg); got != test.expected {
+			t.Errorf("isGitURL(%q) = %v, expected %v", test.arg, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"html"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// templateFuncs returns the functions available in README templates.
+func templateFuncs(r *Readme) template.FuncMap {
+	return template.FuncMap{
+		"code": func(v interface{}) string {
+			if ex, ok := v.(*doc.Example); ok && r.exampleBody {
+				body := *ex
+				body.Play = nil
+				if file, ok := ex.Code.(*ast.File); ok {
+					// just the body of the example function, without
+					// the helper declarations of the whole file example
+					if fun := exampleFunc(file); fun != nil {
+						body.Code = fun.Body
+						body.Comments = nil
+						for _, c := range ex.Comments {
+							if fun.Body.Lbrace < c.Pos() && c.End() < fun.Body.Rbrace {
+								body.Comments = append(body.Comments, c)
+							}
+						}
+					}
+				}
+				v = &body
+			}
+			s, err := renderCode(r.fset, v)
+			if err != nil {
+				panic(err)
+			}
+			return s
+		},
+		"markdown": func(d string) string {
+			opts := r.markdown
+			opts.HeadingLevel = 2
+			return renderMarkdown(d, r.Pkg, opts)
+		},
+		// markdownAt renders headings in d at level, for docs under
+		// sections deeper than the top level
+		"markdownAt": func(level int, d string) string {
+			opts := r.markdown
+			opts.HeadingLevel = level
+			return renderMarkdown(d, r.Pkg, opts)
+		},
+		"apidiff": renderAPIDiff,
+		// fields returns the fields of the struct type declared by decl
+		// if struct types are rendered as tables
+		"fields": func(decl ast.Decl) []StructField {
+			if !r.FieldTables {
+				return nil
+			}
+			return structFields(r.fset, decl)
+		},
+		"decl": func(decl ast.Decl) string {
+			return renderDecl(r.fset, decl)
+		},
+		"section": func(name string) (string, error) {
+			// replaced in render, where the template is available
+			return "", fmt.Errorf("section %q is not available", name)
+		},
+		"snippet": func(name string) (string, error) {
+			s, ok := r.Snippets[name]
+			if !ok {
+				return "", fmt.Errorf("snippet %q is not defined", name)
+			}
+			return s, nil
+		},
+		// slug returns the anchor of a heading, numbering duplicates in the
+		// order of calls
+		"slug": func(heading string) string {
+			if r.slugs == nil {
+				r.slugs = newSlugger()
+			}
+			return r.slugs.slug(heading)
+		},
+		"details":      details,
+		"exampleTitle": exampleTitle,
+		// playLink returns the link to the example on the Go Playground,
+		// which is available with -playground
+		"playLink": func(ex *doc.Example) string {
+			return r.playLinks[ex]
+		},
+		// cell escapes s for a cell of a Markdown table
+		"cell": func(s string) string {
+			return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+		},
+		"fence": func(ft, s string) string {
+			if !strings.HasSuffix(s, "\n") {
+				s = s + "\n"
+			}
+			return "```" + ft + "\n" + s + "```\n"
+		},
+	}
+}
+
+// parseTemplate parses the template tmplContent to render r. Templates in
+// overrides are parsed after it, so that their definitions replace
+// the blocks of the same names.
+func parseTemplate(r *Readme, tmplContent string, overrides ...string) (*template.Template, error) {
+	tmpl, err := template.New("readme").Funcs(templateFuncs(r)).Parse(tmplContent)
+	if err != nil {
+		return nil, withStatus(exitTemplate, err)
+	}
+
+	for i, content := range overrides {
+		_, err := tmpl.New(fmt.Sprintf("override%d", i+1)).Parse(content)
+		if err != nil {
+			return nil, withStatus(exitTemplate, err)
+		}
+	}
+
+	tmpl.Funcs(template.FuncMap{
+		"section": func(name string) (string, error) {
+			var buf bytes.Buffer
+			err := tmpl.ExecuteTemplate(&buf, "section_"+name, r)
+			if err != nil {
+				return "", err
+			}
+			out := buf.String()
+			if r.Collapsed(name) {
+				out = collapseSection(out)
+			}
+			if r.markSections {
+				out = sectionMarker + name + "\n" + out + "\n" + sectionMarker + "\n"
+			}
+			return out, nil
+		},
+	})
+
+	return tmpl, nil
+}
+
+// execute executes the template name in tmpl against r. The anchors of
+// the headings, which the index links to, are collected from the whole
+// README rendered by the template "readme" beforehand, if it is defined.
+func execute(tmpl *template.Template, name string, r *Readme) (string, error) {
+	if tmpl.Lookup(name) == nil {
+		return "", withStatus(exitUsage, fmt.Errorf("template %q is not defined", name))
+	}
+
+	r.anchors = nil
+	if tmpl.Lookup("readme") != nil {
+		r.markSections = true
+		out, err := executeTemplate(tmpl, "readme", r)
+		r.markSections = false
+		if err != nil {
+			return "", err
+		}
+		r.anchors = sectionAnchors(out)
+	}
+
+	return executeTemplate(tmpl, name, r)
+}
+
+func executeTemplate(tmpl *template.Template, name string, r *Readme) (string, error) {
+	r.slugs = newSlugger()
+
+	var buf bytes.Buffer
+	err := tmpl.ExecuteTemplate(&buf, name, r)
+	if err != nil {
+		return "", withStatus(exitTemplate, err)
+	}
+
+	// drop successive empty lines
+	return squeezeEmptyLines(buf.String()), nil
+}
+
+// render executes the template tmplContent against r.
+// See parseTemplate for overrides.
+func render(r *Readme, tmplContent string, overrides ...string) (string, error) {
+	tmpl, err := parseTemplate(r, tmplContent, overrides...)
+	if err != nil {
+		return "", err
+	}
+
+	return execute(tmpl, "readme", r)
+}
+
+// exampleTitle returns a readable title of the example named name,
+// e.g. "Client.Do (with retry)" for "Client_Do_withRetry", which is
+// the name of ExampleClient_Do_withRetry. Names which are not derived
+// from function names are returned as is.
+func exampleTitle(name string) string {
+	if strings.ContainsAny(name, " \t") {
+		return name
+	}
+
+	var suffix string
+	parts := strings.Split(name, "_")
+	if last := parts[len(parts)-1]; len(parts) > 1 && last != "" && unicode.IsLower([]rune(last)[0]) {
+		suffix = splitCamelCase(last)
+		parts = parts[:len(parts)-1]
+	}
+
+	title := strings.Join(parts, ".")
+	if title == "" {
+		title = "Package"
+	}
+	if suffix != "" {
+		title += " (" + suffix + ")"
+	}
+	return title
+}
+
+// splitCamelCase splits s into lowercased words, keeping acronyms as is,
+// e.g. "with HTTP client" for "withHTTPClient".
+func splitCamelCase(s string) string {
+	var words []string
+	rs := []rune(s)
+	start := 0
+	for i := 1; i < len(rs); i++ {
+		upper := unicode.IsUpper(rs[i])
+		if upper && !unicode.IsUpper(rs[i-1]) ||
+			upper && i+1 < len(rs) && unicode.IsLower(rs[i+1]) && unicode.IsUpper(rs[i-1]) {
+			words = append(words, string(rs[start:i]))
+			start = i
+		}
+	}
+	words = append(words, string(rs[start:]))
+
+	for i, w := range words {
+		if w != strings.ToUpper(w) || len([]rune(w)) == 1 {
+			words[i] = strings.ToLower(w)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// details wraps content in a <details> block, which is collapsed
+// under summary.
+func details(summary, content string) string {
+	return "<details>\n<summary>" + html.EscapeString(summary) + "</summary>\n\n" +
+		strings.Trim(content, "\n") + "\n\n</details>\n"
+}
+
+// collapseSection wraps a rendered section in a <details> block,
+// leaving its heading outside so that it can be linked.
+func collapseSection(s string) string {
+	s = strings.Trim(s, "\n")
+	if strings.TrimSpace(s) == "" {
+		return s
+	}
+
+	heading, body := "", s
+	if strings.HasPrefix(s, "#") {
+		heading = s
+		body = ""
+		if i := strings.Index(s, "\n"); i != -1 {
+			heading, body = s[:i], s[i+1:]
+		}
+	}
+	if strings.TrimSpace(body) == "" {
+		return s
+	}
+
+	title := strings.TrimSpace(strings.TrimLeft(heading, "#"))
+	if title == "" {
+		return details("Show", body)
+	}
+	return heading + "\n\n" + details("Show "+title, body)
+}
+
+// renderDecl prints a declaration without its doc comment and function body.
+func renderDecl(fset *token.FileSet, decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		f := *d
+		f.Doc = nil
+		f.Body = nil
+		return nodeString(fset, &f)
+	case *ast.GenDecl:
+		g := *d
+		g.Doc = nil
+		return nodeString(fset, &g)
+	}
+	return nodeString(fset, decl)
+}
+
+// StructField is a field of a struct type rendered in a table.
+type StructField struct {
+	// Name is the name of the field, or the type of an embedded field.
+	Name string
+	Type string
+	// JSON is the json tag of the field, e.g. "id,omitempty".
+	JSON string
+	// Doc is the doc or line comment of the field joined into a line.
+	Doc string
+}
+
+// structFields returns the fields of the struct type declared by decl, or
+// nil if it does not declare a struct type. Unexported fields are already
+// filtered out from the declarations by go/doc unless documenting all.
+func structFields(fset *token.FileSet, decl ast.Decl) []StructField {
+	gen, ok := decl.(*ast.GenDecl)
+	if !ok || gen.Tok != token.TYPE || len(gen.Specs) != 1 {
+		return nil
+	}
+	st, ok := gen.Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+
+	var fields []StructField
+	for _, field := range st.Fields.List {
+		f := StructField{Type: nodeString(fset, field.Type)}
+		if field.Tag != nil {
+			if s, err := strconv.Unquote(field.Tag.Value); err == nil {
+				f.JSON = reflect.StructTag(s).Get("json")
+			}
+		}
+		for _, g := range []*ast.CommentGroup{field.Doc, field.Comment} {
+			if g != nil {
+				f.Doc = strings.Join(strings.Fields(g.Text()), " ")
+				break
+			}
+		}
+		if len(field.Names) == 0 {
+			f.Name = strings.TrimPrefix(f.Type, "*")
+			fields = append(fields, f)
+		}
+		for _, id := range field.Names {
+			f.Name = id.Name
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+var rxOutputPrefix = regexp.MustCompile(`(?i)^[[:space:]]*output:`)
+
+// exampleFunc returns the example function in the file of a whole file
+// example, which has just one. The name of the example may differ from the
+// one of the function if it is titled by its doc.
+func exampleFunc(file *ast.File) *ast.FuncDecl {
+	for _, d := range file.Decls {
+		if fun, ok := d.(*ast.FuncDecl); ok && fun.Recv == nil && strings.HasPrefix(fun.Name.Name, "Example") {
+			return fun
+		}
+	}
+	return nil
+}
+
+func renderCode(fset *token.FileSet, v interface{}) (string, error) {
+	printerConfig := printer.Config{
+		Tabwidth: 4,
+		Mode:     printer.UseSpaces,
+	}
+
+	var buf bytes.Buffer
+
+	if node, ok := v.(ast.Node); ok {
+		var err error
+		if block, ok := node.(*ast.BlockStmt); ok {
+			err = printerConfig.Fprint(&buf, fset, block.List)
+		} else {
+			err = printerConfig.Fprint(&buf, fset, node)
+		}
+		return buf.String(), err
+	}
+
+	if ex, ok := v.(*doc.Example); ok {
+		// Try to remove "Output:" comments
+		comments := make([]*ast.CommentGroup, 0, len(ex.Comments))
+		var outputComment *ast.CommentGroup
+		for _, c := range ex.Comments {
+			if rxOutputPrefix.MatchString(c.Text()) {
+				outputComment = c
+				continue
+			}
+			comments = append(comments, c)
+		}
+
+		if f := ex.Play; f != nil {
+			for _, d := range f.Decls {
+				if fun, ok := d.(*ast.FuncDecl); ok && fun.Name.Name == "main" {
+					trimOutputComment(fun, outputComment)
+				}
+			}
+
+			node := printer.CommentedNode{
+				Node:     f,
+				Comments: comments,
+			}
+			err := printerConfig.Fprint(&buf, fset, &node)
+			return buf.String(), err
+		} else if file, ok := ex.Code.(*ast.File); ok {
+			// a whole file example which is not playable; render the helper
+			// declarations along with the example function, omitting
+			// the package clause and imports
+			var decls []string
+			exFunc := exampleFunc(file)
+			for _, d := range file.Decls {
+				if g, ok := d.(*ast.GenDecl); ok && g.Tok == token.IMPORT {
+					continue
+				}
+				if fun, ok := d.(*ast.FuncDecl); ok && fun == exFunc {
+					f := *fun
+					body := *fun.Body
+					f.Body = &body
+					trimOutputComment(&f, outputComment)
+					d = &f
+				}
+
+				var b bytes.Buffer
+				err := printerConfig.Fprint(&b, fset, &printer.CommentedNode{Node: d, Comments: comments})
+				if err != nil {
+					return "", err
+				}
+				decls = append(decls, b.String())
+			}
+			return strings.Join(decls, "\n\n") + "\n", nil
+		} else if block, ok := ex.Code.(*ast.BlockStmt); ok {
+			// XXX dirty hack: we need BlockStmt code without indentation;
+			// so here we make a fake "switch" statement and remove the
+			// outermost braces.
+			node := printer.CommentedNode{
+				Node:     &ast.SwitchStmt{Body: block},
+				Comments: comments,
+			}
+
+			var b bytes.Buffer
+
+			err := printerConfig.Fprint(&b, fset, &node)
+			if err != nil {
+				return "", err
+			}
+
+			s := b.String()
+			if strings.HasPrefix(s, "switch {\n") && strings.HasSuffix(s, "\n}") {
+				// a leading comment is printed after an empty line
+				s = strings.TrimLeft(s[len("switch {\n"):len(s)-len("\n}")], "\n")
+				return s, nil
+			}
+		}
+
+		node := printer.CommentedNode{
+			Node:     ex.Code,
+			Comments: comments,
+		}
+		err := printerConfig.Fprint(&buf, fset, &node)
+		return buf.String(), err
+	}
+
+	return "", fmt.Errorf("cannot handle %T", v)
+}
+
+// trimOutputComment moves the closing brace of the example function fun
+// to just after its last statement if the output comment is in it,
+// so that no empty line is left after the comment is removed.
+func trimOutputComment(fun *ast.FuncDecl, output *ast.CommentGroup) {
+	if output == nil || fun.Body == nil || len(fun.Body.List) == 0 {
+		return
+	}
+	if fun.Pos() <= output.Pos() && output.Pos() <= fun.End() {
+		fun.Body.Rbrace = fun.Body.List[len(fun.Body.List)-1].End()
+	}
+}
+
+var rxEmptyLines = regexp.MustCompile(`\n{3,}`)
+
+func squeezeEmptyLines(s string) string {
+	return rxEmptyLines.ReplaceAllString(s, "\n\n")
+}
+
+var rxHeading = regexp.MustCompile(`^(#{1,6})(\s|$)`)
+
+// shiftHeadings increases the level of Markdown headings in s by n,
+// leaving fenced code blocks untouched. Levels are capped at 6.
+func shiftHeadings(s string, n int) string {
+	if n <= 0 {
+		return s
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if m := rxHeading.FindStringSubmatch(line); m != nil {
+			level := len(m[1]) + n
+			if level > 6 {
+				level = 6
+			}
+			lines[i] = strings.Repeat("#", level) + line[len(m[1]):]
+		}
+	}
+
+	return strings.Join(lines, "")
+}
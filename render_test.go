@@ -0,0 +1,232 @@
+package main
+
+import (
+	"errors"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShiftHeadings(t *testing.T) {
+	from := "# foo\n\n## Examples\n\n```\n# not a heading\n```\n\n#hashtag\n###### deep\n"
+	to := "### foo\n\n#### Examples\n\n```\n# not a heading\n```\n\n#hashtag\n###### deep\n"
+
+	if got := shiftHeadings(from, 2); got != to {
+		t.Errorf("shiftHeadings mismatch:\nGot ---\n%q\nExpected ---\n%q\n", got, to)
+	}
+}
+
+func TestCollapseSection(t *testing.T) {
+	cases := []struct {
+		from string
+		to   string
+	}{
+		{
+			from: "\n## TODO\n\n- foo\n- bar\n\n",
+			to:   "## TODO\n\n<details>\n<summary>Show TODO</summary>\n\n- foo\n- bar\n\n</details>\n",
+		},
+		{
+			from: "no heading <here>\n",
+			to:   "<details>\n<summary>Show</summary>\n\nno heading <here>\n\n</details>\n",
+		},
+		{
+			from: "## Empty\n",
+			to:   "## Empty",
+		},
+		{
+			from: "\n\n",
+			to:   "",
+		},
+	}
+
+	for _, c := range cases {
+		if got := collapseSection(c.from); got != c.to {
+			t.Errorf("collapseSection mismatch:\nGot ---\n%q\nExpected ---\n%q\n", got, c.to)
+		}
+	}
+}
+
+func TestExampleTitle(t *testing.T) {
+	cases := map[string]string{
+		"":                        "Package",
+		"_basic":                  "Package (basic)",
+		"New":                     "New",
+		"Client":                  "Client",
+		"Client_Do":               "Client.Do",
+		"Client_Do_withRetry":     "Client.Do (with retry)",
+		"Client_Do_usingHTTPAuth": "Client.Do (using HTTP auth)",
+		"Client_Do_v2":            "Client.Do (v2)",
+		"Basic usage":             "Basic usage",
+	}
+
+	for name, title := range cases {
+		if got := exampleTitle(name); got != title {
+			t.Errorf("exampleTitle(%q) = %q, expected %q", name, got, title)
+		}
+	}
+}
+
+func TestRenderCode_wholeFileExample(t *testing.T) {
+	src := `package foo_test
+
+import "fmt"
+
+type helper struct{}
+
+func (helper) Say() { fmt.Println("hi") }
+
+func ExampleSay() {
+	helper{}.Say()
+	// Output: hi
+}
+`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo_test.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exs := doc.Examples(f)
+	if len(exs) != 1 {
+		t.Fatalf("got %d examples", len(exs))
+	}
+	ex := exs[0]
+	ex.Play = nil
+
+	got, err := renderCode(fset, ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type helper struct{}
+
+func (helper) Say() { fmt.Println("hi") }
+
+func ExampleSay() {
+    helper{}.Say()
+}
+`
+	if got != expected {
+		t.Errorf("renderCode mismatch:\nGot ---\n%s\nExpected ---\n%s\n", got, expected)
+	}
+}
+
+func TestTemplateFuncs_codeWholeFileExample(t *testing.T) {
+	src := `package foo_test
+
+import "fmt"
+
+type helper struct{}
+
+func (helper) Say() { fmt.Println("hi") }
+
+func ExampleSay() {
+	// say hi
+	helper{}.Say()
+	// Output: hi
+}
+`
+
+	tests := []struct {
+		exampleBody bool
+		expected    string
+	}{
+		{false, `package main
+
+import "fmt"
+
+type helper struct{}
+
+func (helper) Say() { fmt.Println("hi") }
+
+func main() {
+    // say hi
+    helper{}.Say()
+}
+`},
+		{true, `// say hi
+helper{}.Say()
+`},
+	}
+
+	for _, test := range tests {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "foo_test.go", src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exs := doc.Examples(f)
+		if len(exs) != 1 {
+			t.Fatalf("got %d examples", len(exs))
+		}
+
+		r := &Readme{fset: fset, exampleBody: test.exampleBody}
+		code := templateFuncs(r)["code"].(func(interface{}) string)
+		if got := code(exs[0]); got != test.expected {
+			t.Errorf("exampleBody=%v: code mismatch:\nGot ---\n%s\nExpected ---\n%s\n", test.exampleBody, got, test.expected)
+		}
+	}
+}
+
+func TestStructFields(t *testing.T) {
+	src := `package foo
+
+// User is a user.
+type User struct {
+	// ID is the identifier.
+	ID         int    ` + "`json:\"id\"`" + `
+	Name, Nick string // names
+	*Base
+	secret string
+}
+
+type Kind int
+`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []StructField{
+		{Name: "ID", Type: "int", JSON: "id", Doc: "ID is the identifier."},
+		{Name: "Name", Type: "string", Doc: "names"},
+		{Name: "Nick", Type: "string", Doc: "names"},
+		{Name: "Base", Type: "*Base"},
+		{Name: "secret", Type: "string"},
+	}
+	if got := structFields(fset, f.Decls[0]); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+	if got := structFields(fset, f.Decls[1]); got != nil {
+		t.Errorf("structFields(Kind) = %+v, expected nil", got)
+	}
+}
+
+func TestTemplateFuncs_snippet(t *testing.T) {
+	r := &Readme{Snippets: map[string]string{"footer": "Thanks."}}
+
+	tests := []struct {
+		tmpl     string
+		expected string
+		ok       bool
+	}{
+		{`{{snippet "footer"}}`, "Thanks.", true},
+		{`{{snippet "missing"}}`, "", false},
+	}
+	for _, test := range tests {
+		got, err := render(r, test.tmpl)
+		if test.ok && (err != nil || got != test.expected) {
+			t.Errorf("%s = %q, %v, expected %q", test.tmpl, got, err, test.expected)
+		}
+		var e *exitError
+		if !test.ok && (!errors.As(err, &e) || e.status != exitTemplate || !strings.Contains(err.Error(), `snippet "missing" is not defined`)) {
+			t.Errorf("%s = %q, %v, expected a template error", test.tmpl, got, err)
+		}
+	}
+}
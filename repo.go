@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+)
+
+// Repository is the repository hosting a package.
+type Repository struct {
+	// Kind is the kind of the hosting service, "github", "gitlab",
+	// "bitbucket", "gitea" or "sourcehut".
+	Kind string
+	// Host is the host name, e.g. "github.com".
+	Host string
+	// Path is the path of the repository on the host, e.g. "motemen/goreadme".
+	// GitLab repositories may be in subgroups, e.g. "group/subgroup/project",
+	// and the owners of sourcehut repositories are prefixed by "~".
+	Path string
+}
+
+// Forge returns the hosting service of the repository.
+func (r Repository) Forge() Host {
+	return hosts[r.Kind]
+}
+
+// URL returns the URL of the repository.
+func (r Repository) URL() string {
+	return r.Forge().RepoURL(r)
+}
+
+// SourceURL returns the URL of the file at path in the repository
+// as of ref, e.g. a branch.
+func (r Repository) SourceURL(ref, path string) string {
+	return r.Forge().SourceLink(r, ref, path)
+}
+
+// knownHosts are the kinds of the public hosting services by their hosts.
+var knownHosts = map[string]string{
+	"github.com":    "github",
+	"gitlab.com":    "gitlab",
+	"bitbucket.org": "bitbucket",
+	"codeberg.org":  "gitea",
+	"gitea.com":     "gitea",
+	"git.sr.ht":     "sourcehut",
+}
+
+// resolveRepository returns the repository of the package importPath on
+// the hosts in knownHosts or selfHosted, which maps the hosts of self-hosted
+// services to their kinds, e.g. "gitlab". It returns nil if the host is
+// not known.
+func resolveRepository(importPath string, selfHosted map[string]string) *Repository {
+	parts := strings.Split(importPath, "/")
+	if len(parts) < 3 {
+		return nil
+	}
+
+	host := parts[0]
+	kind, ok := knownHosts[host]
+	if !ok {
+		kind, ok = selfHosted[host]
+	}
+	if !ok {
+		return nil
+	}
+
+	path := parts[1] + "/" + parts[2]
+	if kind == "gitlab" {
+		// repositories in subgroups are imported with the ".git" suffix,
+		// e.g. gitlab.com/group/subgroup/project.git/pkg
+		for i := 1; i < len(parts); i++ {
+			if strings.HasSuffix(parts[i], ".git") {
+				path = strings.TrimSuffix(strings.Join(parts[1:i+1], "/"), ".git")
+				break
+			}
+		}
+	}
+
+	return &Repository{Kind: kind, Host: host, Path: path}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveRepository(t *testing.T) {
+	tests := []struct {
+		importPath string
+		expected   *Repository
+	}{
+		{"github.com/motemen/goreadme", &Repository{Kind: "github", Host: "github.com", Path: "motemen/goreadme"}},
+		{"github.com/motemen/goreadme/sub/pkg", &Repository{Kind: "github", Host: "github.com", Path: "motemen/goreadme"}},
+		{"bitbucket.org/motemen/goreadme/sub", &Repository{Kind: "bitbucket", Host: "bitbucket.org", Path: "motemen/goreadme"}},
+		{"gitlab.com/motemen/goreadme/sub", &Repository{Kind: "gitlab", Host: "gitlab.com", Path: "motemen/goreadme"}},
+		{"gitlab.com/group/subgroup/project.git/pkg", &Repository{Kind: "gitlab", Host: "gitlab.com", Path: "group/subgroup/project"}},
+		{"git.example.com/group/project", &Repository{Kind: "gitlab", Host: "git.example.com", Path: "group/project"}},
+		{"codeberg.org/motemen/goreadme", &Repository{Kind: "gitea", Host: "codeberg.org", Path: "motemen/goreadme"}},
+		{"git.sr.ht/~motemen/goreadme", &Repository{Kind: "sourcehut", Host: "git.sr.ht", Path: "~motemen/goreadme"}},
+		{"example.com/group/project", nil},
+		{"github.com/motemen", nil},
+	}
+
+	for _, test := range tests {
+		got := resolveRepository(test.importPath, map[string]string{"git.example.com": "gitlab"})
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("resolveRepository(%q) = %+v, expected %+v", test.importPath, got, test.expected)
+		}
+	}
+}
+
+func TestRepository_SourceURL(t *testing.T) {
+	tests := []struct {
+		repo     Repository
+		expected string
+	}{
+		{Repository{Kind: "github", Host: "github.com", Path: "o/r"}, "https://github.com/o/r/blob/main/sub/a.go"},
+		{Repository{Kind: "gitlab", Host: "gitlab.com", Path: "o/r"}, "https://gitlab.com/o/r/-/blob/main/sub/a.go"},
+		{Repository{Kind: "bitbucket", Host: "bitbucket.org", Path: "o/r"}, "https://bitbucket.org/o/r/src/main/sub/a.go"},
+		{Repository{Kind: "gitea", Host: "codeberg.org", Path: "o/r"}, "https://codeberg.org/o/r/src/branch/main/sub/a.go"},
+		{Repository{Kind: "sourcehut", Host: "git.sr.ht", Path: "~o/r"}, "https://git.sr.ht/~o/r/tree/main/item/sub/a.go"},
+	}
+
+	for _, test := range tests {
+		if got := test.repo.SourceURL("main", "sub/a.go"); got != test.expected {
+			t.Errorf("SourceURL() of %s = %q, expected %q", test.repo.Kind, got, test.expected)
+		}
+	}
+}
+
+func TestHost_AuthorFromPath(t *testing.T) {
+	repo := Repository{Kind: "sourcehut", Host: "git.sr.ht", Path: "~motemen/goreadme"}
+	author := repo.Forge().AuthorFromPath(repo)
+	expected := Person{Name: "motemen", URL: "https://sr.ht/~motemen"}
+	if author != expected {
+		t.Errorf("AuthorFromPath() = %+v, expected %+v", author, expected)
+	}
+}
+
+func TestHost_ReleasesLink(t *testing.T) {
+	tests := []struct {
+		repo     Repository
+		expected string
+	}{
+		{Repository{Kind: "github", Host: "github.com", Path: "motemen/goreadme"}, "https://github.com/motemen/goreadme/releases"},
+		{Repository{Kind: "gitlab", Host: "gitlab.com", Path: "group/sub/proj"}, "https://gitlab.com/group/sub/proj/-/releases"},
+		{Repository{Kind: "bitbucket", Host: "bitbucket.org", Path: "motemen/goreadme"}, "https://bitbucket.org/motemen/goreadme/downloads/?tab=tags"},
+		{Repository{Kind: "sourcehut", Host: "git.sr.ht", Path: "~motemen/goreadme"}, "https://git.sr.ht/~motemen/goreadme/refs"},
+	}
+
+	for _, test := range tests {
+		if got := test.repo.Forge().ReleasesLink(test.repo); got != test.expected {
+			t.Errorf("ReleasesLink(%+v) = %q, expected %q", test.repo, got, test.expected)
+		}
+	}
+}
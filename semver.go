@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// SemverCheck is the result of "goreadme semver-check".
+type SemverCheck struct {
+	Current string   `json:"current"`
+	Next    string   `json:"next"`
+	Bump    string   `json:"bump"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// runSemverCheck implements "goreadme semver-check [-since REF] [-json] [dir]",
+// which classifies the API changes since the latest tag and suggests
+// the next version.
+func runSemverCheck(args []string) error {
+	flags := flag.NewFlagSet("semver-check", flag.ContinueOnError)
+	since := flags.String("since", "", "git revision of the current version (default: the latest tag)")
+	asJSON := flags.Bool("json", false, "output in JSON")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	dir := "."
+	if flags.NArg() >= 1 {
+		dir = flags.Arg(0)
+	}
+
+	if *since == "" {
+		tag, err := latestTag(dir, "HEAD")
+		if err != nil {
+			return err
+		}
+		*since = tag
+	}
+
+	diff, err := diffAPIBetween(dir, *since, "")
+	if err != nil {
+		return err
+	}
+
+	result := SemverCheck{
+		Current: *since,
+		Bump:    semverBump(diff),
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+	for _, s := range diff.Added {
+		result.Added = append(result.Added, s.Name)
+	}
+	for _, s := range diff.Removed {
+		result.Removed = append(result.Removed, s.Name)
+	}
+	for _, c := range diff.Changed {
+		result.Changed = append(result.Changed, c.New.Name)
+	}
+
+	result.Next, err = nextVersion(result.Current, result.Bump)
+	if err != nil {
+		return withStatus(exitUsage, err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	_, err = fmt.Printf("%s -> %s (%s: %d added, %d removed, %d changed)\n",
+		result.Current, result.Next, result.Bump,
+		len(result.Added), len(result.Removed), len(result.Changed))
+	return err
+}
+
+// semverBump classifies d as "major" if it breaks compatibility,
+// "minor" if it only adds symbols or fields of structs and "patch" otherwise.
+func semverBump(d APIDiff) string {
+	if len(d.Removed) > 0 {
+		return "major"
+	}
+	for _, c := range d.Changed {
+		if !compatibleChange(c) {
+			return "major"
+		}
+	}
+	if len(d.Added) > 0 || len(d.Changed) > 0 {
+		return "minor"
+	}
+	return "patch"
+}
+
+// compatibleChange reports whether c only adds fields to a struct type.
+func compatibleChange(c SymbolChange) bool {
+	if c.Old.Kind != "type" || c.New.Kind != "type" {
+		return false
+	}
+	oldFields, ok := fieldSignatures(c.Old.Signature)
+	if !ok {
+		return false
+	}
+	newFields, ok := fieldSignatures(c.New.Signature)
+	if !ok {
+		return false
+	}
+	for f := range oldFields {
+		if !newFields[f] {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldSignatures parses sig, the declaration of a struct type, and returns
+// its fields, one for each name, e.g. "Name string `json:\"name\"`", with its
+// type parameters. It returns false if sig is not of a struct type.
+func fieldSignatures(sig string) (map[string]bool, bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p\n"+sig, 0)
+	if err != nil || len(f.Decls) != 1 {
+		return nil, false
+	}
+	gen, ok := f.Decls[0].(*ast.GenDecl)
+	if !ok || len(gen.Specs) != 1 {
+		return nil, false
+	}
+	ts := gen.Specs[0].(*ast.TypeSpec)
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok || ts.Assign.IsValid() {
+		return nil, false
+	}
+
+	fields := map[string]bool{}
+	if ts.TypeParams != nil {
+		for _, field := range ts.TypeParams.List {
+			for _, name := range field.Names {
+				fields["["+name.Name+" "+nodeString(fset, field.Type)+"]"] = true
+			}
+		}
+	}
+	for _, field := range st.Fields.List {
+		typ := nodeString(fset, field.Type)
+		if field.Tag != nil {
+			typ += " " + field.Tag.Value
+		}
+		if len(field.Names) == 0 {
+			fields[typ] = true
+		}
+		for _, name := range field.Names {
+			fields[name.Name+" "+typ] = true
+		}
+	}
+	return fields, true
+}
+
+// nextVersion returns the version following v by bump, which is one of
+// "major", "minor" or "patch". As in Go modules, breaking changes
+// in v0 only bump the minor version. The version following a prerelease
+// is its release if the bump does not go beyond it, e.g. v1.2.3 for
+// v1.2.3-rc.1 with a patch bump.
+func nextVersion(v, bump string) (string, error) {
+	if !semver.IsValid(v) {
+		return "", fmt.Errorf("not a semantic version: %q", v)
+	}
+
+	var major, minor, patch int
+	release := strings.TrimSuffix(semver.Canonical(v), semver.Prerelease(v))
+	if _, err := fmt.Sscanf(release, "v%d.%d.%d", &major, &minor, &patch); err != nil {
+		return "", fmt.Errorf("not a semantic version: %q", v)
+	}
+	pre := semver.Prerelease(v) != ""
+
+	if bump == "major" && major == 0 {
+		bump = "minor"
+	}
+
+	switch {
+	case bump == "major" && pre && minor == 0 && patch == 0:
+	case bump == "major":
+		major, minor, patch = major+1, 0, 0
+	case bump == "minor" && pre && patch == 0:
+	case bump == "minor":
+		minor, patch = minor+1, 0
+	case pre:
+	default:
+		patch++
+	}
+
+	return fmt.Sprintf("v%d.%d.%d", major, minor, patch), nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNextVersion(t *testing.T) {
+	cases := []struct {
+		version string
+		bump    string
+		next    string
+	}{
+		{"v1.2.3", "patch", "v1.2.4"},
+		{"v1.2.3", "minor", "v1.3.0"},
+		{"v1.2.3", "major", "v2.0.0"},
+		{"v0.4.1", "major", "v0.5.0"},
+		{"v1.2.3+build", "patch", "v1.2.4"},
+		{"v1.2.3-rc.1", "patch", "v1.2.3"},
+		{"v1.2.3-rc.1", "minor", "v1.3.0"},
+		{"v1.2.0-rc.1", "minor", "v1.2.0"},
+		{"v1.2.0-rc.1", "major", "v2.0.0"},
+		{"v2.0.0-beta", "major", "v2.0.0"},
+		{"v0.1.0-alpha", "major", "v0.1.0"},
+	}
+
+	for _, c := range cases {
+		next, err := nextVersion(c.version, c.bump)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next != c.next {
+			t.Errorf("nextVersion(%q, %q) = %q, expected %q", c.version, c.bump, next, c.next)
+		}
+	}
+
+	for _, v := range []string{"1.2", "v1.2.x", ""} {
+		if _, err := nextVersion(v, "patch"); err == nil {
+			t.Errorf("nextVersion(%q) should fail for non-semver", v)
+		}
+	}
+}
+
+func TestSemverBump(t *testing.T) {
+	typ := func(sig string) Symbol {
+		return Symbol{Name: "T", Kind: "type", Signature: sig}
+	}
+	fn := func(name, sig string) Symbol {
+		return Symbol{Name: name, Kind: "func", Signature: sig}
+	}
+
+	cases := []struct {
+		name     string
+		diff     APIDiff
+		expected string
+	}{
+		{"empty", APIDiff{}, "patch"},
+		{"added", APIDiff{Added: []Symbol{fn("F", "func F()")}}, "minor"},
+		{"removed", APIDiff{Removed: []Symbol{fn("F", "func F()")}}, "major"},
+		{
+			"func changed",
+			APIDiff{Changed: []SymbolChange{{Old: fn("F", "func F()"), New: fn("F", "func F(n int)")}}},
+			"major",
+		},
+		{
+			"field added",
+			APIDiff{Changed: []SymbolChange{{
+				Old: typ("type T struct {\n    A, B int\n}"),
+				New: typ("type T struct {\n    A int\n    B int\n    C string `json:\"c\"`\n    io.Reader\n}"),
+			}}},
+			"minor",
+		},
+		{
+			"field removed",
+			APIDiff{Changed: []SymbolChange{{
+				Old: typ("type T struct {\n    A int\n    B int\n}"),
+				New: typ("type T struct {\n    A int\n}"),
+			}}},
+			"major",
+		},
+		{
+			"field type changed",
+			APIDiff{Changed: []SymbolChange{{
+				Old: typ("type T struct {\n    A int\n}"),
+				New: typ("type T struct {\n    A int64\n}"),
+			}}},
+			"major",
+		},
+		{
+			"type parameter changed",
+			APIDiff{Changed: []SymbolChange{{
+				Old: typ("type T[E any] struct {\n    A E\n}"),
+				New: typ("type T[E comparable] struct {\n    A E\n    B int\n}"),
+			}}},
+			"major",
+		},
+		{
+			"interface method added",
+			APIDiff{Changed: []SymbolChange{{
+				Old: typ("type T interface {\n    A()\n}"),
+				New: typ("type T interface {\n    A()\n    B()\n}"),
+			}}},
+			"major",
+		},
+		{
+			"struct to alias",
+			APIDiff{Changed: []SymbolChange{{
+				Old: typ("type T struct {\n    A int\n}"),
+				New: typ("type T = struct {\n    A int\n}"),
+			}}},
+			"major",
+		},
+	}
+
+	for _, c := range cases {
+		if got := semverBump(c.diff); got != c.expected {
+			t.Errorf("%s: semverBump = %q, expected %q", c.name, got, c.expected)
+		}
+	}
+}
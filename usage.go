@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flag is a command line flag of a command defined with the flag package.
+type Flag struct {
+	// Name is the name of the flag without the leading "-".
+	Name string
+	// Shorthand is the one-letter abbreviation of the flag of cobra or
+	// urfave/cli commands.
+	Shorthand string
+	// Type is the type of the value, e.g. "string", or "" for flag.Var.
+	Type string
+	// Default is the default value as in the source, or "" if it is the
+	// zero value.
+	Default string
+	// Usage is the usage message, or "" if it is not a constant string.
+	Usage string
+}
+
+// flagFuncs are the functions defining flags of the flag package and the
+// methods of flag.FlagSet, with the indices of their name, default value
+// and usage arguments. -1 is for none.
+var flagFuncs = map[string]struct {
+	args               int
+	name, value, usage int
+	typ                string
+}{
+	"Bool":        {3, 0, 1, 2, "bool"},
+	"BoolVar":     {4, 1, 2, 3, "bool"},
+	"Int":         {3, 0, 1, 2, "int"},
+	"IntVar":      {4, 1, 2, 3, "int"},
+	"Int64":       {3, 0, 1, 2, "int64"},
+	"Int64Var":    {4, 1, 2, 3, "int64"},
+	"Uint":        {3, 0, 1, 2, "uint"},
+	"UintVar":     {4, 1, 2, 3, "uint"},
+	"Uint64":      {3, 0, 1, 2, "uint64"},
+	"Uint64Var":   {4, 1, 2, 3, "uint64"},
+	"String":      {3, 0, 1, 2, "string"},
+	"StringVar":   {4, 1, 2, 3, "string"},
+	"Float64":     {3, 0, 1, 2, "float64"},
+	"Float64Var":  {4, 1, 2, 3, "float64"},
+	"Duration":    {3, 0, 1, 2, "duration"},
+	"DurationVar": {4, 1, 2, 3, "duration"},
+	"TextVar":     {4, 1, 2, 3, ""},
+	"Func":        {3, 0, -1, 1, ""},
+	"BoolFunc":    {3, 0, -1, 1, ""},
+	"Var":         {3, 1, -1, 2, ""},
+}
+
+// zeroValues are the default values not shown, as the flag package does.
+var zeroValues = map[string]bool{`false`: true, `0`: true, `""`: true, "``": true, `0.0`: true}
+
+// commandFlags returns the flags defined by calling the functions of
+// the flag package, or the methods of the same names of flag.CommandLine,
+// with constant names in files except tests, in the order of appearance.
+// Flags of other FlagSets, e.g. of subcommands, are not the flags of the
+// command and are left out.
+func commandFlags(fset *token.FileSet, files []*ast.File) []Flag {
+	var flags []Flag
+	seen := map[string]bool{}
+	for _, f := range files {
+		if strings.HasSuffix(fset.Position(f.Package).Filename, "_test.go") {
+			continue
+		}
+		pkgName := importName(f, "flag")
+		if pkgName == "" {
+			continue
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || !isCommandLine(sel.X, pkgName) {
+				return true
+			}
+			fn, ok := flagFuncs[sel.Sel.Name]
+			if !ok || len(call.Args) != fn.args {
+				return true
+			}
+			name, ok := stringConstant(call.Args[fn.name])
+			if !ok || name == "" || seen[name] {
+				return true
+			}
+
+			flag := Flag{Name: name, Type: fn.typ}
+			flag.Usage, _ = stringConstant(call.Args[fn.usage])
+			if fn.value != -1 {
+				if v := exprString(fset, call.Args[fn.value]); !zeroValues[v] {
+					flag.Default = v
+				}
+			}
+			seen[name] = true
+			flags = append(flags, flag)
+			return true
+		})
+	}
+	return flags
+}
+
+// importName returns the name by which f refers to the package of path, or
+// "" if f does not import it by a name.
+func importName(f *ast.File, path string) string {
+	for _, imp := range f.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err != nil || p != path {
+			continue
+		}
+		if imp.Name == nil {
+			return filepath.Base(path)
+		}
+		if imp.Name.Name == "_" || imp.Name.Name == "." {
+			return ""
+		}
+		return imp.Name.Name
+	}
+	return ""
+}
+
+// isCommandLine reports whether expr is the flag package named pkgName, or
+// its CommandLine FlagSet.
+func isCommandLine(expr ast.Expr, pkgName string) bool {
+	if sel, ok := expr.(*ast.SelectorExpr); ok && sel.Sel.Name == "CommandLine" {
+		expr = sel.X
+	}
+	id, ok := expr.(*ast.Ident)
+	// a package name is not resolved to a local object by the parser
+	return ok && id.Name == pkgName && id.Obj == nil
+}
+
+// stringConstant returns the value of the string literal, or the
+// concatenation of them, expr.
+func stringConstant(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := stringConstant(e.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := stringConstant(e.Y)
+		return x + y, ok
+	case *ast.ParenExpr:
+		return stringConstant(e.X)
+	}
+	return "", false
+}
+
+// exprString returns the source of expr.
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	s, err := renderCode(fset, expr)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// runHelp builds the command in dir with "go build" and returns the output
+// of running it with -h, with the path of the binary replaced by name.
+func runHelp(dir, name string) (string, error) {
+	tmp, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	bin := filepath.Join(tmp, "cmd")
+	build := exec.Command("go", "build", "-o", bin, ".")
+	build.Dir = dir
+	if out, err := build.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("go build: %v\n%s", err, out)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, bin, "-h")
+	cmd.Dir = dir
+	// the flag package exits with 0 for -h since Go 1.15 and 2 before
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("running %s -h: %v", name, ctx.Err())
+	}
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		return "", err
+	}
+
+	// the name of the binary in the usage, e.g. "Usage of /tmp/.../cmd:"
+	help := strings.Replace(string(out), bin, name, -1)
+	return strings.TrimRight(help, "\n") + "\n", nil
+}
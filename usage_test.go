@@ -0,0 +1,112 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCommandFlags(t *testing.T) {
+	src := `package main
+
+import (
+	"flag"
+	"time"
+)
+
+const usage = "not a literal"
+
+func main() {
+	verbose := flag.Bool("v", false, "verbose output")
+	flag.StringVar(&name, "name", "world", "name to " +
+		"greet")
+	flags := flag.NewFlagSet("sub", flag.ExitOnError)
+	flags.DurationVar(&timeout, "timeout", 30*time.Second, usage)
+	flags.Var(&list, "tag", "tags (repeatable)")
+	flag.Int("n", 0, "count")
+	flag.Int("v", 1, "duplicate")
+	s := b.String()
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Flag{
+		{Name: "v", Type: "bool", Usage: "verbose output"},
+		{Name: "name", Type: "string", Default: `"world"`, Usage: "name to greet"},
+		{Name: "n", Type: "int", Usage: "count"},
+	}
+	if got := commandFlags(fset, []*ast.File{f}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+}
+
+func TestCommandFlags_flagSets(t *testing.T) {
+	src := `package main
+
+import (
+	goflag "flag"
+	"os"
+)
+
+func main() {
+	goflag.CommandLine.String("config", "", "config file")
+	add := goflag.NewFlagSet("add", goflag.ExitOnError)
+	add.Bool("force", false, "overwrite existing entries")
+	rm := goflag.NewFlagSet("rm", goflag.ExitOnError)
+	rm.Bool("force", true, "remove without asking")
+	goflag.Bool("force", false, "force everything")
+	flag := add
+	flag.String("name", "", "not a global flag")
+	goflag.Parse()
+	rm.Parse(os.Args[1:])
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Flag{
+		{Name: "config", Type: "string", Usage: "config file"},
+		{Name: "force", Type: "bool", Usage: "force everything"},
+	}
+	if got := commandFlags(fset, []*ast.File{f}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Got ---\n%+v\nExpected ---\n%+v", got, expected)
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goreadme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"go.mod":  "module example.com/hello\n",
+		"main.go": "package main\n\nimport \"flag\"\n\nfunc main() {\n\tflag.String(\"name\", \"world\", \"name to greet\")\n\tflag.Parse()\n}\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	help, err := runHelp(dir, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Usage of hello:\n  -name string\n    \tname to greet (default \"world\")\n"
+	if help != expected {
+		t.Errorf("runHelp mismatch:\nGot ---\n%q\nExpected ---\n%q\n", help, expected)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// goImportURLPrefix is prepended to import paths to fetch their go-import
+// meta tags.
+var goImportURLPrefix = "https://"
+
+var (
+	rxMetaTag  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	rxMetaAttr = regexp.MustCompile(`(?is)\b(name|content)\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// metaTags returns the contents of the <meta> tags named name in the HTML.
+func metaTags(html, name string) []string {
+	var contents []string
+	for _, tag := range rxMetaTag.FindAllString(html, -1) {
+		attrs := map[string]string{}
+		for _, m := range rxMetaAttr.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(m[1])] = m[2][1 : len(m[2])-1]
+		}
+		if attrs["name"] == name {
+			contents = append(contents, attrs["content"])
+		}
+	}
+	return contents
+}
+
+// vanityImports caches the results of resolveVanityImport by the prefixes
+// of the go-import meta tags, so that the packages of a module, e.g. the
+// ones given to -w, are looked up once.
+var vanityImports = map[string][]string{}
+
+// resolveVanityImport fetches the go-import and go-source meta tags of
+// importPath and returns the URLs of the repository candidates they refer to,
+// the root of the go-import one first.
+func resolveVanityImport(importPath string) ([]string, error) {
+	for prefix, urls := range vanityImports {
+		if importPath == prefix || strings.HasPrefix(importPath, prefix+"/") {
+			return urls, nil
+		}
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(goImportURLPrefix + importPath + "?go-get=1")
+	if err != nil {
+		return nil, withStatus(exitVCSOrNetwork, err)
+	}
+	defer resp.Body.Close()
+
+	// the meta tags are in <head>
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, withStatus(exitVCSOrNetwork, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, withStatus(exitVCSOrNetwork, fmt.Errorf("resolving %s: %s", importPath, resp.Status))
+	}
+
+	matches := func(prefix string) bool {
+		return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+	}
+
+	var urls []string
+	var root string
+	// "prefix vcs repo-root"
+	for _, content := range metaTags(string(b), "go-import") {
+		if f := strings.Fields(content); len(f) == 3 && matches(f[0]) && f[1] != "mod" {
+			urls = append(urls, f[2])
+			root = f[0]
+		}
+	}
+	// "prefix home directory file"
+	for _, content := range metaTags(string(b), "go-source") {
+		if f := strings.Fields(content); len(f) == 4 && matches(f[0]) && f[1] != "_" {
+			urls = append(urls, f[1])
+		}
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("resolving %s: no go-import meta tag found", importPath)
+	}
+	if root != "" {
+		vanityImports[root] = urls
+	}
+
+	return urls, nil
+}
+
+// repositoryFromURL returns the repository at the URL such as
+// "https://github.com/uber-go/zap.git", or nil if the host is not known.
+// See resolveRepository for selfHosted.
+func repositoryFromURL(url string, selfHosted map[string]string) *Repository {
+	if i := strings.Index(url, "://"); i != -1 {
+		url = url[i+len("://"):]
+	}
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	if i := strings.Index(url, "@"); i != -1 && i < strings.Index(url, "/") {
+		// drop user info, e.g. git@github.com
+		url = url[i+1:]
+	}
+	url = strings.Replace(url, ":", "/", 1)
+
+	return resolveRepository(url, selfHosted)
+}
+
+// resolveRepositoryOf returns the repository of the package importPath.
+// If its host is not known, the repository is looked up over the network by
+// the go-import meta tags of the vanity import path, which the "repository"
+// configuration avoids.
+func resolveRepositoryOf(importPath string, selfHosted map[string]string) (*Repository, error) {
+	if repo := resolveRepository(importPath, selfHosted); repo != nil {
+		return repo, nil
+	}
+
+	// build.ImportDir returns "." for the directories outside GOPATH and
+	// modules
+	if build.IsLocalImport(importPath) || !strings.Contains(importPath, "/") {
+		return nil, nil
+	}
+
+	// only domain names may be vanity import paths
+	host := strings.SplitN(importPath, "/", 2)[0]
+	if !strings.Contains(host, ".") {
+		return nil, nil
+	}
+
+	urls, err := resolveVanityImport(importPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, url := range urls {
+		if repo := repositoryFromURL(url, selfHosted); repo != nil {
+			return repo, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveRepositoryOf_vanity(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if req.URL.Query().Get("go-get") != "1" || !strings.HasPrefix(req.URL.Path, "/zap") {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write([]byte(`<!DOCTYPE html>
+<html>
+<head>
+<meta name="go-import" content="` + req.Host + `/zap git https://github.com/uber-go/zap">
+<meta content="` + req.Host + `/zap https://github.com/uber-go/zap https://github.com/uber-go/zap/tree/master{/dir} https://github.com/uber-go/zap/tree/master{/dir}/{file}#L{line}" name="go-source">
+</head>
+</html>`))
+	}))
+	defer ts.Close()
+
+	defer func(prefix string) { goImportURLPrefix = prefix }(goImportURLPrefix)
+	goImportURLPrefix = "http://"
+	defer func(cache map[string][]string) { vanityImports = cache }(vanityImports)
+	vanityImports = map[string][]string{}
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	repo, err := resolveRepositoryOf(host+"/zap/zapcore", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Repository{Kind: "github", Host: "github.com", Path: "uber-go/zap"}
+	if !reflect.DeepEqual(repo, expected) {
+		t.Errorf("resolveRepositoryOf = %+v, expected %+v", repo, expected)
+	}
+
+	// cached by the go-import prefix
+	repo, err = resolveRepositoryOf(host+"/zap", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repo, expected) {
+		t.Errorf("resolveRepositoryOf = %+v, expected %+v", repo, expected)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, expected 1", requests)
+	}
+
+	if _, err := resolveRepositoryOf(host+"/unknown", nil); err == nil {
+		t.Error("resolveRepositoryOf should fail without go-import meta tags")
+	}
+}
+
+func TestResolveRepositoryOf_local(t *testing.T) {
+	defer func(prefix string) { goImportURLPrefix = prefix }(goImportURLPrefix)
+	goImportURLPrefix = "http://invalid.test/"
+
+	for _, importPath := range []string{".", "./foo", "example.com", "foo/bar"} {
+		repo, err := resolveRepositoryOf(importPath, nil)
+		if repo != nil || err != nil {
+			t.Errorf("resolveRepositoryOf(%q) = %+v, %v, expected nil", importPath, repo, err)
+		}
+	}
+}
+
+func TestLoadReadme_vanity(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.Write([]byte(`<meta name="go-import" content="example.test/zap git https://github.com/uber-go/zap">`))
+	}))
+	defer ts.Close()
+
+	defer func(prefix string) { goImportURLPrefix = prefix }(goImportURLPrefix)
+	goImportURLPrefix = ts.URL + "/"
+	defer func(cache map[string][]string) { vanityImports = cache }(vanityImports)
+	vanityImports = map[string][]string{}
+
+	files := map[string]string{
+		"go.mod": "module example.test/zap\n",
+		"zap.go": "package zap\n",
+	}
+
+	// not looked up unless asked to
+	if r := testReadme(t, files, loadOptions{}); r.Repository != nil || requests != 0 {
+		t.Errorf("Repository = %+v with %d requests, expected nil without requests", r.Repository, requests)
+	}
+
+	r := testReadme(t, files, loadOptions{ResolveVanity: true})
+	expected := &Repository{Kind: "github", Host: "github.com", Path: "uber-go/zap"}
+	if !reflect.DeepEqual(r.Repository, expected) || requests != 1 {
+		t.Errorf("Repository = %+v with %d requests, expected %+v with 1 request", r.Repository, requests, expected)
+	}
+}
+
+func TestRepositoryFromURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected *Repository
+	}{
+		{"https://github.com/uber-go/zap.git", &Repository{Kind: "github", Host: "github.com", Path: "uber-go/zap"}},
+		{"git@gitlab.com:motemen/goreadme.git", &Repository{Kind: "gitlab", Host: "gitlab.com", Path: "motemen/goreadme"}},
+		{"https://go.googlesource.com/tools", nil},
+	}
+
+	for _, test := range tests {
+		if got := repositoryFromURL(test.url, nil); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("repositoryFromURL(%q) = %+v, expected %+v", test.url, got, test.expected)
+		}
+	}
+}
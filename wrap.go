@@ -0,0 +1,133 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// rxBlockMarker matches words which would start a Markdown block, such as
+// a list item or a heading, if they were at the beginning of a line.
+var rxBlockMarker = regexp.MustCompile(`^(?:[-+*]|>.*|#{1,6}|[0-9]{1,9}[.)]|=+|-+)$`)
+
+// noBreakBefore are the characters which should not start a line
+// in East Asian texts.
+const noBreakBefore = "、。，．）」』】〉》！？：；"
+
+// isWide reports whether r occupies two columns, as East Asian wide and
+// fullwidth characters do.
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r >= 0x3000 && r <= 0x303F || // CJK symbols and punctuations
+		r >= 0xFF01 && r <= 0xFF60 || r >= 0xFFE0 && r <= 0xFFE6 // fullwidth forms
+}
+
+// textWidth returns the number of columns s occupies.
+func textWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case isWide(r):
+			w += 2
+		default:
+			w++
+		}
+	}
+	return w
+}
+
+type wrapWord struct {
+	text string
+	// space is true if the word is separated from the previous one by a space.
+	space bool
+}
+
+// splitWords splits s into words at white spaces. Each wide character is
+// a word by itself, as texts in East Asian languages can be broken between
+// any characters. Line breaks between wide characters do not separate
+// words with spaces.
+func splitWords(s string) []wrapWord {
+	var (
+		words []wrapWord
+		word  strings.Builder
+		prev  rune // the last rune of the previous word
+		sep   rune // the separator before the next word: 0, ' ' or '\n'
+	)
+
+	add := func(text string) {
+		first, _ := utf8.DecodeRuneInString(text)
+		space := sep == ' ' || sep == '\n' && !(isWide(prev) && isWide(first))
+		words = append(words, wrapWord{text: text, space: space && len(words) > 0})
+		prev, _ = utf8.DecodeLastRuneInString(text)
+		sep = 0
+	}
+	flush := func() {
+		if word.Len() > 0 {
+			add(word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+			if r == '\n' && sep == 0 {
+				sep = '\n'
+			} else {
+				sep = ' '
+			}
+		case isWide(r):
+			flush()
+			add(string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+func canStartLine(word string) bool {
+	r, _ := utf8.DecodeRuneInString(word)
+	return !rxBlockMarker.MatchString(word) && !strings.ContainsRune(noBreakBefore, r)
+}
+
+// reflow fills the lines of the paragraph s up to width columns.
+// If width is 0, s is joined into a line.
+func reflow(s string, width int) string {
+	var b strings.Builder
+
+	col := 0
+	for _, w := range splitWords(s) {
+		ww := textWidth(w.text)
+		sep := 0
+		if w.space {
+			sep = 1
+		}
+
+		if width > 0 && col > 0 && col+sep+ww > width && canStartLine(w.text) {
+			b.WriteString("\n")
+			col = 0
+		} else if w.space {
+			b.WriteString(" ")
+			col++
+		}
+
+		b.WriteString(w.text)
+		col += ww
+	}
+
+	return b.String()
+}
+
+// maxInt returns the larger of a and b.
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestReflow(t *testing.T) {
+	cases := []struct {
+		from  string
+		width int
+		to    string
+	}{
+		{
+			from:  "the quick brown fox\njumps over the lazy dog",
+			width: 0,
+			to:    "the quick brown fox jumps over the lazy dog",
+		},
+		{
+			from:  "the quick brown fox jumps over the lazy dog",
+			width: 15,
+			to:    "the quick brown\nfox jumps over\nthe lazy dog",
+		},
+		{
+			// words which would start a list are not put at the start of a line
+			from:  "one two - three",
+			width: 7,
+			to:    "one two -\nthree",
+		},
+		{
+			from:  "日本語の\n文章です。",
+			width: 0,
+			to:    "日本語の文章です。",
+		},
+		{
+			from:  "Goの日本語の文章です。",
+			width: 10,
+			to:    "Goの日本語\nの文章です。",
+		},
+		{
+			from:  "verylongword short",
+			width: 5,
+			to:    "verylongword\nshort",
+		},
+	}
+
+	for _, c := range cases {
+		if got := reflow(c.from, c.width); got != c.to {
+			t.Errorf("reflow(%q, %d) mismatch:\nGot ---\n%s\nExpected ---\n%s\n", c.from, c.width, got, c.to)
+		}
+	}
+}